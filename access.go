@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetRotateInterval 设置按时间轮转的间隔, 文件在间隔的整数倍时刻(如每小时整点)轮转, 0表示只按大小轮转
+func (l *Logger) SetRotateInterval(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rotateInterval = d
+	now := time.Now()
+	for _, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok {
+			sb.rotateAt = l.nextRotation(now)
+		}
+	}
+}
+
+// SetIdleRotation 设置空闲轮转: 文件超过d没有写入时, 下一条日志写入新文件, 使间歇运行的批处理任务每次运行各有一个文件; 0表示关闭
+func (l *Logger) SetIdleRotation(d time.Duration) {
+	l.mu.Lock()
+	l.idleRotate = d
+	l.mu.Unlock()
+}
+
+// nextRotation returns when a file opened at now is due for time-based
+// rotation, or the zero time without it. The wall clock picks the
+// boundary, but the result is now plus the time left until it, so that it
+// keeps now's monotonic reading and a step of the wall clock neither
+// rotates early nor holds rotation back.
+// l.mu is held.
+func (l *Logger) nextRotation(now time.Time) time.Time {
+	if l.rotateInterval <= 0 {
+		return time.Time{}
+	}
+	boundary := now.Truncate(l.rotateInterval).Add(l.rotateInterval) // no monotonic reading
+	return now.Add(boundary.Sub(now))
+}
+
+// SetCallerLookup 设置是否记录调用位置, 关闭可省去每条日志的栈查找
+func (l *Logger) SetCallerLookup(enabled bool) {
+	var v int32
+	if !enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.noCaller, v)
+}
+
+// NewAccessLogger 创建适合大量访问日志的Logger: 只写Info级别的dir/access.INFO.*文件,
+// 不记录调用位置, 不输出stderr, 每小时轮转, JSON格式; 可用SetEncoder(CombinedEncoder{})改为Apache/NCSA格式
+func NewAccessLogger(dir string) *Logger {
+	l := &Logger{}
+	l.SetLogDir(dir)
+	l.SetLogName("access")
+	l.SetSeverityLimit(SeverityInfo)
+	l.SetCallerLookup(false)
+	l.SetRotateInterval(time.Hour)
+	l.SetEncoder(JSONEncoder{})
+	l.noStderr = true
+	return l
+}
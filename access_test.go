@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRotation(t *testing.T) {
+	l := &Logger{}
+	now := time.Now()
+	if next := l.nextRotation(now); !next.IsZero() {
+		t.Errorf("nextRotation without an interval = %v, want zero", next)
+	}
+
+	l.rotateInterval = time.Hour
+	next := l.nextRotation(now)
+	boundary := now.Truncate(time.Hour).Add(time.Hour)
+	if !next.Equal(boundary) {
+		t.Errorf("nextRotation(%v) = %v, want %v", now, next, boundary)
+	}
+	// Only a time with a monotonic reading differs from its Round(0).
+	if next == next.Round(0) {
+		t.Error("nextRotation dropped the monotonic reading, so wall clock steps would move rotation")
+	}
+}
@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminState is the body of the admin endpoint's responses and PUT
+// requests. Fields left out of a PUT keep their value.
+type adminState struct {
+	Level     *Severity `json:"level,omitempty"`
+	Verbosity *int      `json:"verbosity,omitempty"`
+	VModule   *string   `json:"vmodule,omitempty"`
+}
+
+// adminElevation is the body of an elevate request.
+type adminElevation struct {
+	Level    Severity `json:"level"`
+	Duration string   `json:"duration"` // time.ParseDuration syntax
+}
+
+// AdminHandler 返回运行时调整日志的http.Handler, 需由调用方挂载并自行做访问控制:
+// GET返回当前的日志级别、V日志详细级别与vmodule; PUT以同样格式的JSON修改, 省略的字段不变;
+// 路径以/flush结尾的POST刷新日志缓冲; 以/config结尾的GET返回Config();
+// 以/elevate结尾的POST按{"level":"DEBUG","duration":"10m"}调用ElevateLevel.
+// 修改设置的请求不受日志级别限制地写一条审计日志
+func (l *Logger) AdminHandler() http.Handler {
+	return http.HandlerFunc(l.serveAdmin)
+}
+
+// allowOnly rejects requests whose method isn't method.
+func allowOnly(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+func (l *Logger) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/flush"):
+		if allowOnly(w, r, http.MethodPost) {
+			l.Flush()
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	case strings.HasSuffix(r.URL.Path, "/config"):
+		if allowOnly(w, r, http.MethodGet) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(l.Config())
+		}
+		return
+	case strings.HasSuffix(r.URL.Path, "/elevate"):
+		if allowOnly(w, r, http.MethodPost) {
+			l.serveElevate(w, r)
+		}
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		var req adminState
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Verbosity != nil && *req.Verbosity < 0 {
+			http.Error(w, "verbosity must not be negative", http.StatusBadRequest)
+			return
+		}
+		// Validate everything before changing anything.
+		if req.VModule != nil {
+			if _, err := parseVModule(*req.VModule); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetVModule(*req.VModule)
+		}
+		if req.Level != nil {
+			l.SetSeverityLimit(*req.Level)
+		}
+		if req.Verbosity != nil {
+			l.SetVerbosity(*req.Verbosity)
+		}
+		st := l.adminState()
+		l.adminAudit("logger settings changed", r,
+			Field{Key: "level", Value: st.Level.String()},
+			Field{Key: "verbosity", Value: strconv.Itoa(*st.Verbosity)},
+			Field{Key: "vmodule", Value: *st.VModule})
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.adminState())
+}
+
+func (l *Logger) serveElevate(w http.ResponseWriter, r *http.Request) {
+	var req adminElevation
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 {
+		http.Error(w, "duration must be a positive duration such as \"10m\"", http.StatusBadRequest)
+		return
+	}
+	l.ElevateLevel(req.Level, d)
+	l.adminAudit("logger level elevated", r,
+		Field{Key: "level", Value: req.Level.String()},
+		Field{Key: "duration", Value: d.String()})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.adminState())
+}
+
+// adminAudit records a change made through the admin handler. It bypasses
+// the severity limit, which the change itself may have raised.
+func (l *Logger) adminAudit(msg string, r *http.Request, fields ...Field) {
+	e := &Entry{
+		Severity: SeverityInfo,
+		Time:     time.Now(),
+		File:     "admin",
+		Message:  msg,
+		Fields:   append([]Field{{Key: "by", Value: r.RemoteAddr}}, fields...),
+		targeted: true,
+	}
+	l.log(e)
+}
+
+func (l *Logger) adminState() adminState {
+	level := l.severityLimit.get()
+	verbosity := l.Verbosity()
+	vmodule := l.VModule()
+	return adminState{Level: &level, Verbosity: &verbosity, VModule: &vmodule}
+}
+
+// AdminHandler 默认logger快捷调用
+func AdminHandler() http.Handler {
+	return DefaultLogger.AdminHandler()
+}
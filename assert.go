@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// SetDevelopment 设置开发模式: 断言失败时写Fatal日志并退出进程, 否则只写Error日志
+func (l *Logger) SetDevelopment(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.development, v)
+}
+
+func (l *Logger) isDevelopment() bool {
+	return atomic.LoadInt32(&l.development) != 0
+}
+
+// assertFailed logs a failed assertion with the stack of its caller's
+// caller.
+func (l *Logger) assertFailed(msg string) {
+	msg = "assertion failed: " + msg + "\n" + stack()
+	if l.isDevelopment() {
+		l.fatal(1, msg, nil, 0)
+		return
+	}
+	if !l.enabled(SeverityError) {
+		return
+	}
+	l.log(l.newEntry(SeverityError, 0, msg))
+}
+
+// Assert cond为false时记录断言失败及调用栈: 开发模式下写Fatal并退出, 否则写Error; 返回cond,
+// 便于写作if !l.Assert(ok, "...") { return }
+func (l *Logger) Assert(cond bool, msg string) bool {
+	if !cond {
+		l.assertFailed(msg)
+	}
+	return cond
+}
+
+// Assertf 同Assert, 消息按format格式化
+func (l *Logger) Assertf(cond bool, format string, args ...interface{}) bool {
+	if !cond {
+		l.assertFailed(fmt.Sprintf(format, args...))
+	}
+	return cond
+}
+
+// AssertfDev cond为false时记录格式化的断言失败及调用栈: 生产环境写Error, 开发模式(SetDevelopment)下写Fatal并退出; 返回cond
+func (l *Logger) AssertfDev(cond bool, format string, args ...interface{}) bool {
+	if !cond {
+		l.assertFailed(fmt.Sprintf(format, args...))
+	}
+	return cond
+}
+
+// Assert 默认logger快捷调用
+func Assert(cond bool, msg string) bool {
+	if !cond {
+		DefaultLogger.assertFailed(msg)
+	}
+	return cond
+}
+
+// Assertf 默认logger快捷调用
+func Assertf(cond bool, format string, args ...interface{}) bool {
+	if !cond {
+		DefaultLogger.assertFailed(fmt.Sprintf(format, args...))
+	}
+	return cond
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"sync/atomic"
+)
+
+// asyncQueue hands entries from logging goroutines to a single writer
+// goroutine.
+type asyncQueue struct {
+	ch        chan *Entry
+	done      chan struct{}
+	abandoned int32 // set when Drain gave up; remaining entries are dropped
+}
+
+// SetAsync 设置异步写日志, size为队列长度, size<=0时恢复同步写并写出队列中的日志
+func (l *Logger) SetAsync(size int) {
+	l.asyncMu.Lock()
+	old := l.async
+	l.async = nil
+	if size > 0 {
+		q := &asyncQueue{
+			ch:   make(chan *Entry, size),
+			done: make(chan struct{}),
+		}
+		if l.daemons.spawn(func(<-chan struct{}) { l.asyncWriter(q) }) {
+			l.async = q
+		}
+	}
+	l.asyncMu.Unlock()
+	if old != nil {
+		close(old.ch)
+		<-old.done
+	}
+}
+
+// enqueue queues e for the async writer, reporting false in sync mode.
+func (l *Logger) enqueue(e *Entry) bool {
+	l.asyncMu.RLock()
+	q := l.async
+	if q != nil {
+		memAcquire(entryCost(e))
+		q.ch <- e
+	}
+	l.asyncMu.RUnlock()
+	return q != nil
+}
+
+func (l *Logger) asyncWriter(q *asyncQueue) {
+	for e := range q.ch {
+		if e.done != nil {
+			close(e.done)
+			continue
+		}
+		memRelease(entryCost(e))
+		if atomic.LoadInt32(&q.abandoned) != 0 {
+			atomic.AddUint64(&l.dropped, 1)
+			continue
+		}
+		l.write(e)
+	}
+	close(q.done)
+}
+
+// settle waits until the entries queued so far, including those of the
+// class loggers, have been written.
+func (l *Logger) settle() {
+	var done chan struct{}
+	l.asyncMu.RLock()
+	if l.async != nil {
+		done = make(chan struct{})
+		l.async.ch <- &Entry{done: done}
+	}
+	l.asyncMu.RUnlock()
+	if done != nil {
+		<-done
+	}
+	for _, c := range l.classes() {
+		c.settle()
+	}
+}
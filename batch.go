@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// WriteBatch 在一次加锁中将多条已格式化的日志原样写入sev及以下级别的日志文件, 用于回放等批量写入;
+// 每条日志缺少结尾换行时自动补齐, 不经过Sink, 低于日志级别时丢弃; sev未注册时返回错误
+func (l *Logger) WriteBatch(sev Severity, entries [][]byte) error {
+	if !sev.valid() {
+		return errUnknownSeverity(sev)
+	}
+	if len(entries) == 0 || !sev.atLeast(l.severityLimit.get()) {
+		return nil
+	}
+	bufs := make([]*buffer, len(entries))
+	for i, p := range entries {
+		buf := _bufferPool.getBuffer()
+		buf.Write(p)
+		if len(p) == 0 || p[len(p)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		bufs[i] = buf
+	}
+	atomic.AddUint64(&l.written[sev.index()], uint64(len(bufs)))
+	if l.stalled() {
+		for _, buf := range bufs {
+			l.writeStalledEntry(buf)
+		}
+		return nil
+	}
+	if l.reentrant() {
+		for _, buf := range bufs {
+			l.writeReentrant(buf)
+		}
+		return nil
+	}
+
+	l.mu.Lock()
+	slimit := l.severityLimit.get()
+	mirror := l.mirrors(sev, slimit)
+	if !l.noFiles {
+		if l.openFiles(sev, slimit) {
+			for _, buf := range bufs {
+				for _, i := range severityTab().span(slimit, sev) {
+					l.file[i.index()].writeBuffer(buf)
+				}
+			}
+		} else {
+			mirror = true // don't lose the entries; fall back to stderr
+		}
+	}
+	var out *os.File
+	if mirror {
+		out = l.consoleOut(sev)
+	}
+	tee := out == os.Stderr && l.stderrTee
+	if tee {
+		for _, buf := range bufs {
+			l.stderr.add(buf)
+		}
+	} else if out != nil {
+		raw := make([][]byte, len(bufs))
+		for i, buf := range bufs {
+			raw[i] = buf.Bytes()
+		}
+		writeBuffers(out, raw)
+	}
+	l.mu.Unlock()
+	for _, buf := range bufs {
+		_bufferPool.release(buf)
+	}
+	l.startFlushDaemon()
+	if tee {
+		l.stderr.flush()
+	}
+	if sev.atLeast(SeverityError) {
+		l.Flush()
+	}
+	return nil
+}
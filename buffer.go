@@ -3,6 +3,7 @@ package logger
 import (
 	"bytes"
 	"sync"
+	"sync/atomic"
 )
 
 const digits = "0123456789"
@@ -11,6 +12,12 @@ type buffer struct {
 	bytes.Buffer
 	tmp  [64]byte
 	next *buffer
+	refs int32 // references held by syncBuffers, plus one for the owner
+}
+
+// retain adds a reference to buf; each reference is dropped with release.
+func (buf *buffer) retain() {
+	atomic.AddInt32(&buf.refs, 1)
 }
 
 // twoDigits formats a zero-prefixed two-digit integer at buf.tmp[i].
@@ -67,16 +74,27 @@ func (bp *bufferPool) getBuffer() *buffer {
 	if b == nil {
 		b = new(buffer)
 	} else {
+		memRelease(b.Cap())
 		b.next = nil
 		b.Reset()
 	}
+	b.refs = 1
 	return b
 }
 
+// release drops a reference to b and returns it to the pool once the last
+// reference is gone.
+func (bp *bufferPool) release(b *buffer) {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		bp.putBuffer(b)
+	}
+}
+
 func (bp *bufferPool) putBuffer(b *buffer) {
-	if b.Len() >= 512 {
+	if b.Len() >= 512 || memOver() {
 		return
 	}
+	memAcquire(b.Cap())
 	bp.mtx.Lock()
 	b.next = bp.freeList
 	bp.freeList = b
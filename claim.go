@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+)
+
+// ErrDuplicateLogger 另一个Logger已在使用相同的日志路径和文件名
+var ErrDuplicateLogger = errors.New("logger: log dir and name already in use by another Logger")
+
+// openFiles records which Logger owns each dir+name pair. Two Loggers writing
+// the same files through separate bufio writers would interleave partially
+// buffered records, so the second one is refused instead.
+var openFiles = struct {
+	sync.Mutex
+	owners map[string]*Logger
+}{owners: make(map[string]*Logger)}
+
+// claimFiles registers l as the owner of its current dir+name.
+// l.mu is held.
+func (l *Logger) claimFiles() error {
+	if l.claimKey != "" {
+		return nil
+	}
+	key := filepath.Join(l.getLogDir(), l.getLogName())
+	openFiles.Lock()
+	defer openFiles.Unlock()
+	if owner, ok := openFiles.owners[key]; ok && owner != l {
+		return ErrDuplicateLogger
+	}
+	openFiles.owners[key] = l
+	l.claimKey = key
+	return nil
+}
+
+// releaseFiles gives up l's claim so another Logger may use the dir+name.
+// l.mu is held.
+func (l *Logger) releaseFiles() {
+	if l.claimKey == "" {
+		return
+	}
+	openFiles.Lock()
+	if openFiles.owners[l.claimKey] == l {
+		delete(openFiles.owners, l.claimKey)
+	}
+	openFiles.Unlock()
+	l.claimKey = ""
+}
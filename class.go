@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"time"
+)
+
+// ClassKey 标记日志内容类别的字段名
+const ClassKey = "class"
+
+// Class 创建内容类别字段, 如Class("personal-data")
+func Class(name string) Field {
+	return Field{Key: ClassKey, Value: name}
+}
+
+// SetClassRetention 将带有Class(class)字段的日志写入单独的文件组(<日志名>.<class>.<级别>...),
+// 这组文件保留maxAge后由清理机制删除, 与其他日志的SetMaxAge互不影响
+func (l *Logger) SetClassRetention(class string, maxAge time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.classes()
+	if c, ok := old[class]; ok {
+		c.SetMaxAge(maxAge)
+		return
+	}
+	c := &Logger{
+		logDir:         l.getLogDir(),
+		logName:        sanitizeName(l.getLogName() + "." + class),
+		maxSize:        l.getMaxSize(),
+		maxAge:         maxAge,
+		noStderr:       l.noStderr,
+		stderrLevel:    l.stderrLevel,
+		stderrLevelSet: l.stderrLevelSet,
+		consoleSplit:   l.consoleSplit,
+	}
+	c.updateSinkFloor()
+	c.encoder.Store(encoderHolder{l.getEncoder()})
+	c.severityLimit.set(l.severityLimit.get())
+	classes := make(map[string]*Logger, len(old)+1)
+	for n, o := range old {
+		classes[n] = o
+	}
+	classes[class] = c
+	l.classLoggers.Store(classes)
+	l.daemons.spawn(c.retentionDaemon)
+}
+
+func (l *Logger) classes() map[string]*Logger {
+	m, _ := l.classLoggers.Load().(map[string]*Logger)
+	return m
+}
+
+// classLogger returns the Logger for the content class of e, or nil when
+// e belongs to l's own files.
+func (l *Logger) classLogger(e *Entry) *Logger {
+	classes := l.classes()
+	if len(classes) == 0 {
+		return nil
+	}
+	for _, f := range e.Fields {
+		if f.Key == ClassKey {
+			if c := classes[f.String()]; c != nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// syncClassLimits gives the class loggers l's severity limit, after it
+// changed.
+func (l *Logger) syncClassLimits() {
+	s := l.severityLimit.get()
+	for _, c := range l.classes() {
+		c.severityLimit.set(s)
+	}
+}
+
+// writeClass writes e to the files of its class logger c. Everything else
+// about e is l's: the class loggers have no sinks of their own.
+func (l *Logger) writeClass(c *Logger, e *Entry) {
+	l.mu.Lock()
+	l.writeSinks(e)
+	l.mu.Unlock()
+	c.output(e, c.encode(e))
+}
+
+// retentionDaemon enforces maxAge between rotations, which may be far
+// apart for a class file set that is written rarely.
+func (l *Logger) retentionDaemon(stop <-chan struct{}) {
+	for {
+		l.mu.Lock()
+		interval := l.maxAge / 4
+		l.mu.Unlock()
+		if interval < time.Minute {
+			interval = time.Minute
+		} else if interval > time.Hour {
+			interval = time.Hour
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		l.mu.Lock()
+		l.cleanup()
+		l.mu.Unlock()
+	}
+}
+
+// closeClasses closes the class file sets.
+func (l *Logger) closeClasses() {
+	for _, c := range l.classes() {
+		c.Close()
+	}
+}
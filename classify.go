@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrorClassifier 根据错误决定Errorw日志的级别
+type ErrorClassifier func(error) Severity
+
+// DefaultErrorClassifier 默认分类: io.EOF与context.Canceled记为Info, 其余记为Error
+func DefaultErrorClassifier(err error) Severity {
+	if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+		return SeverityInfo
+	}
+	return SeverityError
+}
+
+// SetErrorClassifier 设置Errorw使用的错误分类, nil表示DefaultErrorClassifier
+func (l *Logger) SetErrorClassifier(fn ErrorClassifier) {
+	l.classifier.Store(fn)
+}
+
+// classify returns the severity for an Errorw entry carrying fields.
+func (l *Logger) classify(fields []Field) Severity {
+	for _, f := range fields {
+		if err, ok := f.Value.(error); ok && err != nil {
+			fn, _ := l.classifier.Load().(ErrorClassifier)
+			if fn == nil {
+				fn = DefaultErrorClassifier
+			}
+			return fn(err)
+		}
+	}
+	return SeverityError
+}
+
+// WithError 创建名为error的日志字段
+func WithError(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+func (l *Logger) printw(msg string, fields []Field) {
+	s := l.classify(fields)
+	if !l.enabled(s) {
+		return
+	}
+	e := l.newEntry(s, 0, msg)
+	e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], fields...)
+	l.log(e)
+}
+
+// Errorw 写带字段的错误日志, 级别由首个错误字段(如WithError)经ErrorClassifier决定, 无错误字段时为Error
+func (l *Logger) Errorw(msg string, fields ...Field) {
+	l.printw(msg, fields)
+}
+
+// Errorw 默认logger快捷调用
+func Errorw(msg string, fields ...Field) {
+	DefaultLogger.printw(msg, fields)
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"flag"
+	"os"
+	"strconv"
+)
+
+// CLIFlags 命令行工具的日志标志: -q安静模式, -v可重复使用, -vv相当于两个-v
+type CLIFlags struct {
+	Quiet   bool
+	Verbose int
+}
+
+// countFlag is a boolean flag that adds step to n each time it is set.
+type countFlag struct {
+	n    *int
+	step int
+}
+
+func (c countFlag) String() string {
+	if c.n == nil {
+		return "0"
+	}
+	return strconv.Itoa(*c.n)
+}
+
+func (c countFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*c.n += c.step
+	}
+	return nil
+}
+
+func (c countFlag) IsBoolFlag() bool {
+	return true
+}
+
+// RegisterFlags 在fs上注册-q, -v与-vv
+func (f *CLIFlags) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&f.Quiet, "q", false, "quiet: no log output on the console, log files are still written")
+	fs.Var(countFlag{&f.Verbose, 1}, "v", "more verbose console output, may be repeated")
+	fs.Var(countFlag{&f.Verbose, 2}, "vv", "same as -v -v")
+}
+
+// Severity 返回标志对应的日志级别: 默认Warning, 每个-v降低一级直到Trace, -q时为Error
+func (f CLIFlags) Severity() Severity {
+	if f.Quiet {
+		return SeverityError
+	}
+	s := SeverityWarning - Severity(f.Verbose)
+	if s < SeverityTrace {
+		s = SeverityTrace
+	}
+	return s
+}
+
+// ApplyCLI 按命令行标志设置日志: 控制台显示f.Severity()及以上的日志, 日志文件至少记录Info;
+// -q时不输出控制台但仍写日志文件; 未指定-v且stdout不是终端(如被管道或重定向)时控制台再提高一级
+func (l *Logger) ApplyCLI(f CLIFlags) {
+	console := f.Severity()
+	if f.Verbose == 0 && !isTerminal(os.Stdout) && console < SeverityFatal {
+		console++
+	}
+	limit := console
+	if limit > SeverityInfo {
+		limit = SeverityInfo
+	}
+	l.SetSeverityLimit(limit)
+	l.SetStderrThreshold(console)
+	l.SetStderrOutput(!f.Quiet)
+}
+
+// isTerminal reports whether f is a character device, as terminals are.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ApplyCLI 默认logger快捷调用
+func ApplyCLI(f CLIFlags) {
+	DefaultLogger.ApplyCLI(f)
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// clockStepThreshold is how far the wall clock may fall behind the
+// monotonic clock between two entries before it counts as a step back.
+const clockStepThreshold = 10 * time.Millisecond
+
+// checkClock detects the wall clock stepping back between the previous
+// entry and e, and writes a marker entry ahead of e when it did.
+// Comparing against the monotonic reading keeps entries that were merely
+// reordered by contention from counting as a step.
+// l.mu is held.
+func (l *Logger) checkClock(e *Entry, slimit Severity) {
+	prev := l.lastEntryTime
+	l.lastEntryTime = e.Time
+	if prev.IsZero() {
+		return
+	}
+	step := e.Time.Sub(prev) - e.Time.Round(0).Sub(prev.Round(0))
+	if step <= clockStepThreshold {
+		return
+	}
+	atomic.AddUint64(&l.clockSteps, 1)
+	atomic.StoreInt64(&l.lastClockStep, int64(step))
+	m := &Entry{
+		Severity: SeverityWarning,
+		Time:     e.Time,
+		File:     "clock",
+		Message:  "wall clock stepped back",
+		Fields: []Field{
+			{Key: "step", Value: step.String()},
+			{Key: "prev", Value: prev.Round(0).Format(time.RFC3339Nano)},
+		},
+	}
+	l.writeSinks(m)
+	if l.noFiles {
+		return
+	}
+	fs := m.Severity
+	if !fs.atLeast(slimit) {
+		fs = slimit
+	}
+	buf := l.encode(m)
+	l.writeFiles(fs, slimit, buf)
+	_bufferPool.release(buf)
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxCmdLine is the longest line kept waiting for its newline; longer
+// output is written out in pieces.
+const maxCmdLine = 64 * 1024
+
+// cmdWriter turns a child process's output into one entry per line.
+type cmdWriter struct {
+	l      *Logger
+	sev    Severity
+	prefix string
+	stream string
+	source string
+	file   string
+	line   int
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *cmdWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	for len(w.buf) >= maxCmdLine {
+		w.emit(w.buf[:maxCmdLine])
+		w.buf = w.buf[maxCmdLine:]
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// flush writes out a last line that had no newline.
+func (w *cmdWriter) flush() {
+	w.mu.Lock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	w.mu.Unlock()
+}
+
+// emit logs one line. w.mu is held.
+func (w *cmdWriter) emit(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if !w.l.enabled(w.sev) {
+		return
+	}
+	w.l.log(&Entry{
+		Severity: w.sev,
+		Time:     time.Now(),
+		File:     w.file,
+		Line:     w.line,
+		Message:  w.prefix + string(line),
+		Fields:   []Field{{Key: SourceKey, Value: w.source}, {Key: "stream", Value: w.stream}},
+	})
+}
+
+// WrapCmd 将cmd的stdout和stderr按行写入s级别日志, 每行以"[命令名] "开头并带有source=child:命令名与stream字段,
+// 调用位置记为WrapCmd的调用处; 须在cmd.Start之前调用. cmd.Wait返回后调用flush写出末尾未换行的内容
+func (l *Logger) WrapCmd(cmd *exec.Cmd, s Severity) (flush func()) {
+	_, file, line := caller(1)
+	return l.wrapCmd(cmd, s, filepath.Base(cmd.Path), file, line)
+}
+
+// WrapCmdAs 同WrapCmd, 以name代替命令名, 用于区分同一程序的多个子进程, 如"worker-3"
+func (l *Logger) WrapCmdAs(cmd *exec.Cmd, s Severity, name string) (flush func()) {
+	_, file, line := caller(1)
+	return l.wrapCmd(cmd, s, name, file, line)
+}
+
+func (l *Logger) wrapCmd(cmd *exec.Cmd, s Severity, name, file string, line int) (flush func()) {
+	prefix := "[" + name + "] "
+	source := "child:" + name
+	stdout := &cmdWriter{l: l, sev: s, prefix: prefix, stream: "stdout", source: source, file: file, line: line}
+	stderr := &cmdWriter{l: l, sev: s, prefix: prefix, stream: "stderr", source: source, file: file, line: line}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return func() {
+		stdout.flush()
+		stderr.flush()
+	}
+}
@@ -0,0 +1,58 @@
+// Command vglog-import 将JSON lines、logfmt或glog格式的日志导入vglog日志文件, 原始时间保存在orig_time字段
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	logger "github.com/panlibin/vglog"
+	"github.com/panlibin/vglog/reader"
+)
+
+func main() {
+	dir := flag.String("dir", "./log", "destination log directory")
+	name := flag.String("name", "import", "destination log name")
+	format := flag.String("format", "json", "input format: json, logfmt or glog")
+	debug := flag.Bool("debug", false, "import debug and trace entries too (a Debug limit also mirrors them to stderr)")
+	flag.Parse()
+
+	l := &logger.Logger{}
+	l.SetLogDir(*dir)
+	l.SetLogName(*name)
+	l.SetSeverityLimit(logger.SeverityInfo)
+	if *debug {
+		l.SetSeverityLimit(logger.SeverityTrace)
+	}
+	defer l.Close()
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	total := 0
+	for _, path := range inputs {
+		n, err := importFile(l, path, reader.Format(*format))
+		total += n
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			l.Close()
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("imported %d entries\n", total)
+}
+
+func importFile(l *logger.Logger, path string, format reader.Format) (int, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return reader.Import(l, r, format)
+}
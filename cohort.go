@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// cohortRule selects the entries whose key field hashes below threshold.
+type cohortRule struct {
+	key       string
+	threshold uint64
+}
+
+// SetCohortSampling 按字段值抽样: 字段key的值落在rate比例内的日志条目(如1%的用户ID)
+// 不受SetSeverityLimit限制, 写入最低级别的日志文件及未单独设置级别的Sink.
+// 同一值总是得到同样的结果. key为空或rate<=0时关闭
+func (l *Logger) SetCohortSampling(key string, rate float64) {
+	if key == "" || rate <= 0 {
+		l.cohort.Store((*cohortRule)(nil))
+		return
+	}
+	threshold := uint64(math.MaxUint32) + 1
+	if rate < 1 {
+		threshold = uint64(rate * float64(threshold))
+	}
+	l.cohort.Store(&cohortRule{key: key, threshold: threshold})
+}
+
+func (l *Logger) cohortRule() *cohortRule {
+	r, _ := l.cohort.Load().(*cohortRule)
+	return r
+}
+
+// inCohort reports whether e carries the sampled field with a value that
+// falls into the cohort.
+func (l *Logger) inCohort(e *Entry) bool {
+	r := l.cohortRule()
+	if r == nil {
+		return false
+	}
+	for _, f := range e.Fields {
+		if f.Key == r.key {
+			h := fnv.New64a()
+			h.Write([]byte(f.String()))
+			return mix64(h.Sum64())>>32 < r.threshold
+		}
+	}
+	return false
+}
+
+// mix64 spreads FNV's weak high bits for short keys such as small IDs.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/panlibin/vglog/internal/zmsg"
+)
+
+// CompressedPrefix 标记被压缩的消息: 其后是压缩方式名(如zstd, 旧文件中为deflate)、':'和压缩再base64编码的原消息,
+// 可用reader.Expand还原
+const CompressedPrefix = zmsg.Prefix
+
+// SetCompressThreshold 设置消息压缩阈值: 写入文件时长于n字节的消息以zstd压缩并base64编码为一行,
+// 加CompressedPrefix标记, 使偶尔的巨大转储不占据文件大小; n<=0关闭压缩(默认); Sink收到的是原消息
+func (l *Logger) SetCompressThreshold(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&l.compressThreshold, int32(n))
+}
+
+// CompressThreshold 返回消息压缩阈值, 0表示不压缩
+func (l *Logger) CompressThreshold() int {
+	return int(atomic.LoadInt32(&l.compressThreshold))
+}
+
+// compressed returns e, or a copy of it with the message compressed when
+// it is over the threshold and compression actually saves space.
+func (l *Logger) compressed(e *Entry) *Entry {
+	n := int(atomic.LoadInt32(&l.compressThreshold))
+	if n <= 0 || len(e.Message) <= n {
+		return e
+	}
+	z := zmsg.Compress(e.Message)
+	if len(z) >= len(e.Message) {
+		return e
+	}
+	c := *e
+	c.Message = z
+	return &c
+}
+
+// expandCompressed returns text with its compressed messages expanded, so
+// that they can be searched.
+func expandCompressed(text []byte) []byte {
+	if !bytes.Contains(text, []byte(CompressedPrefix)) {
+		return text
+	}
+	s, _ := zmsg.Expand(string(text))
+	return []byte(s)
+}
@@ -0,0 +1,334 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Config 日志配置, 可序列化为JSON; 零值字段使用默认值
+type Config struct {
+	Dir            string            `json:"dir"`                    // 日志目录
+	Name           string            `json:"name"`                   // 日志文件名
+	Level          Severity          `json:"level"`                  // 日志文件级别
+	MaxSize        uint64            `json:"max_size"`               // 文件轮转大小
+	MaxAge         time.Duration     `json:"max_age"`                // 文件保留时长
+	MinFreeSpace   uint64            `json:"min_free_space"`         // 最小磁盘剩余空间
+	DisableFiles   bool              `json:"disable_files"`          // 不写日志文件
+	LinkFormat     string            `json:"link_format"`            // 符号链接名格式
+	NoLinks        bool              `json:"no_links"`               // 不创建符号链接
+	Encoder        string            `json:"encoder"`                // 已注册的编码器名
+	EncoderParams  map[string]string `json:"encoder_params"`         // 编码器参数
+	Sinks          []SinkConfig      `json:"sinks"`                  // 额外的输出目标
+	Async          int               `json:"async"`                  // 异步队列长度
+	RotateInterval time.Duration     `json:"rotate_interval"`        // 按时间轮转的间隔
+	AtomicFinalize bool              `json:"atomic_finalize"`        // 临时文件写完后改名
+	IdleRotate     time.Duration     `json:"idle_rotate"`            // 空闲多久后下一条日志写入新文件
+	Counters       map[string]string `json:"counters"`               // 计数器名到正则表达式
+	Verbosity      int               `json:"verbosity"`              // V日志的详细级别
+	VModule        string            `json:"vmodule"`                // 按源文件的V日志详细级别, 如"conn*=3,db=1"
+	CompressOver   int               `json:"compress_over"`          // 长于此字节数的消息压缩后写入, 0不压缩
+	DisableStderr  bool              `json:"disable_stderr"`         // 不镜像日志到stderr
+	StderrLevel    *Severity         `json:"stderr_level,omitempty"` // 镜像到stderr的最低级别, 为nil时按Level决定
+}
+
+// SinkConfig 按注册名创建的输出目标
+type SinkConfig struct {
+	Name      string            `json:"name"`
+	Params    map[string]string `json:"params"`
+	Threshold *Severity         `json:"threshold,omitempty"` // 为nil时沿用Level
+}
+
+// ValidateConfig 检查配置而不启动Logger: 目录可写, 链接格式与正则表达式有效, 选项互不冲突,
+// 编码器已注册, Sink可以创建并Flush; 返回发现的所有问题
+func ValidateConfig(cfg Config) error {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	if !cfg.Level.valid() {
+		add("level %d out of range", cfg.Level)
+	}
+	if cfg.StderrLevel != nil && !cfg.StderrLevel.valid() {
+		add("stderr_level %d out of range", *cfg.StderrLevel)
+	}
+	if cfg.CompressOver < 0 {
+		add("compress_over %d is negative", cfg.CompressOver)
+	}
+	if cfg.Async < 0 {
+		add("async queue length %d is negative", cfg.Async)
+	}
+	if cfg.MaxAge < 0 || cfg.RotateInterval < 0 || cfg.IdleRotate < 0 {
+		add("max_age, rotate_interval and idle_rotate must not be negative")
+	}
+	if cfg.DisableFiles {
+		if cfg.MaxSize != 0 || cfg.MaxAge != 0 || cfg.MinFreeSpace != 0 || cfg.RotateInterval != 0 ||
+			cfg.IdleRotate != 0 || cfg.AtomicFinalize || cfg.LinkFormat != "" || cfg.Dir != "" {
+			add("file options set while disable_files is set")
+		}
+		if len(cfg.Sinks) == 0 {
+			add("disable_files is set and there are no sinks")
+		}
+	} else {
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "./log/"
+		}
+		if err := checkWritable(convDirAbs(dir)); err != nil {
+			add("%v", err)
+		}
+	}
+	if cfg.NoLinks && cfg.LinkFormat != "" {
+		add("link_format set while no_links is set")
+	}
+	if cfg.LinkFormat != "" && !strings.Contains(cfg.LinkFormat, "{tag}") {
+		add("link_format %q lacks {tag}, all severities would share one link", cfg.LinkFormat)
+	}
+	if strings.ContainsAny(cfg.LinkFormat, `/\`) {
+		add("link_format %q contains a path separator", cfg.LinkFormat)
+	}
+	for name, expr := range cfg.Counters {
+		if _, err := regexp.Compile(expr); err != nil {
+			add("counter %s: %v", name, err)
+		}
+	}
+	if _, err := parseVModule(cfg.VModule); err != nil {
+		add("vmodule: %v", err)
+	}
+	if cfg.Encoder != "" {
+		if _, err := NewEncoder(cfg.Encoder, cfg.EncoderParams); err != nil {
+			add("encoder: %v", err)
+		}
+	}
+	for i, sc := range cfg.Sinks {
+		if sc.Threshold != nil && !sc.Threshold.valid() {
+			add("sink %d (%s): threshold %d out of range", i, sc.Name, *sc.Threshold)
+		}
+		sink, err := NewSink(sc.Name, sc.Params)
+		if err != nil {
+			add("sink %d (%s): %v", i, sc.Name, err)
+			continue
+		}
+		if err := sink.Flush(); err != nil {
+			add("sink %d (%s): %v", i, sc.Name, err)
+		}
+		if c, ok := sink.(io.Closer); ok {
+			c.Close()
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("logger: invalid config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// checkWritable checks that log files could be created in dir, probing
+// the nearest existing ancestor when dir doesn't exist yet, so nothing is
+// left behind.
+func checkWritable(dir string) error {
+	probe := dir
+	for {
+		fi, err := os.Stat(probe)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("log dir %s: %s is not a directory", dir, probe)
+			}
+			break
+		}
+		parent := filepath.Dir(probe)
+		if parent == probe {
+			return fmt.Errorf("log dir %s: %v", dir, err)
+		}
+		probe = parent
+	}
+	f, err := ioutil.TempFile(probe, ".probe")
+	if err != nil {
+		return fmt.Errorf("log dir %s is not writable: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// Configure 校验并应用配置, Sinks追加到已有的输出目标
+func (l *Logger) Configure(cfg Config) error {
+	if err := ValidateConfig(cfg); err != nil {
+		return err
+	}
+	var enc Encoder
+	if cfg.Encoder != "" {
+		var err error
+		if enc, err = NewEncoder(cfg.Encoder, cfg.EncoderParams); err != nil {
+			return err
+		}
+	}
+	sinks := make([]Sink, len(cfg.Sinks))
+	for i, sc := range cfg.Sinks {
+		var err error
+		if sinks[i], err = NewSink(sc.Name, sc.Params); err != nil {
+			return err
+		}
+	}
+
+	l.SetFileOutput(!cfg.DisableFiles)
+	if cfg.Dir != "" {
+		l.SetLogDir(cfg.Dir)
+	}
+	if cfg.Name != "" {
+		l.SetLogName(cfg.Name)
+	}
+	l.SetSeverityLimit(cfg.Level)
+	l.SetStderrOutput(!cfg.DisableStderr)
+	if cfg.StderrLevel != nil {
+		l.SetStderrThreshold(*cfg.StderrLevel)
+	}
+	if cfg.MaxSize != 0 {
+		l.SetMaxSize(cfg.MaxSize)
+	}
+	l.SetMaxAge(cfg.MaxAge)
+	l.SetMinFreeSpace(cfg.MinFreeSpace)
+	switch {
+	case cfg.NoLinks:
+		l.SetLinkFormat("")
+	case cfg.LinkFormat != "":
+		l.SetLinkFormat(cfg.LinkFormat)
+	}
+	l.SetEncoder(enc)
+	l.SetRotateInterval(cfg.RotateInterval)
+	l.SetIdleRotation(cfg.IdleRotate)
+	l.SetAtomicFinalize(cfg.AtomicFinalize)
+	l.SetVerbosity(cfg.Verbosity)
+	l.SetCompressThreshold(cfg.CompressOver)
+	if err := l.SetVModule(cfg.VModule); err != nil {
+		return err
+	}
+	for name, expr := range cfg.Counters {
+		if err := l.RegisterCounter(name, expr); err != nil {
+			return err
+		}
+	}
+	for i, sink := range sinks {
+		as := &addedSink{sink: sink, config: &SinkConfig{Name: cfg.Sinks[i].Name, Params: cfg.Sinks[i].Params}}
+		if t := cfg.Sinks[i].Threshold; t != nil {
+			as.threshold, as.hasThreshold = *t, true
+		}
+		l.mu.Lock()
+		l.sinks = append(l.sinks, as)
+		l.updateSinkFloor()
+		l.mu.Unlock()
+	}
+	l.SetAsync(cfg.Async)
+	return nil
+}
+
+// Config 返回生效中的配置, 默认值已填入; 不是通过Configure添加的Sink以其类型名表示
+func (l *Logger) Config() Config {
+	l.mu.Lock()
+	cfg := l.lockedConfig()
+	l.mu.Unlock()
+
+	l.asyncMu.RLock()
+	if l.async != nil {
+		cfg.Async = cap(l.async.ch)
+	}
+	l.asyncMu.RUnlock()
+	l.counters.mu.RLock()
+	if len(l.counters.list) > 0 {
+		cfg.Counters = make(map[string]string, len(l.counters.list))
+		for _, lc := range l.counters.list {
+			cfg.Counters[lc.name] = lc.re.String()
+		}
+	}
+	l.counters.mu.RUnlock()
+	return cfg
+}
+
+// lockedConfig returns the configuration without the async queue and the
+// counters, which are guarded by their own locks.
+// l.mu is held.
+func (l *Logger) lockedConfig() Config {
+	cfg := Config{
+		Dir:            l.getLogDir(),
+		Name:           l.getLogName(),
+		Level:          l.severityLimit.get(),
+		MaxSize:        l.getMaxSize(),
+		MaxAge:         l.maxAge,
+		MinFreeSpace:   atomic.LoadUint64(&l.minFreeSpace),
+		DisableFiles:   l.noFiles,
+		LinkFormat:     defaultLinkFormat,
+		RotateInterval: l.rotateInterval,
+		IdleRotate:     l.idleRotate,
+		AtomicFinalize: l.atomicFinalize,
+	}
+	if l.linkSet {
+		cfg.LinkFormat = l.linkFormat
+		cfg.NoLinks = l.linkFormat == ""
+	}
+	if l.noFiles {
+		// None of the file settings are in effect.
+		cfg = Config{Name: cfg.Name, Level: cfg.Level, DisableFiles: true}
+	}
+	cfg.DisableStderr = l.noStderr
+	if l.stderrLevelSet {
+		s := l.stderrLevel
+		cfg.StderrLevel = &s
+	}
+	cfg.Verbosity = l.Verbosity()
+	cfg.VModule = l.VModule()
+	cfg.CompressOver = l.CompressThreshold()
+	for _, as := range l.sinks {
+		var sc SinkConfig
+		if as.config != nil {
+			sc = *as.config
+		} else {
+			sc.Name = fmt.Sprintf("%T", as.sink)
+		}
+		if as.hasThreshold {
+			t := as.threshold
+			sc.Threshold = &t
+		}
+		cfg.Sinks = append(cfg.Sinks, sc)
+	}
+	cfg.Encoder, cfg.EncoderParams = encoderConfig(l.getEncoder())
+	return cfg
+}
+
+// encoderConfig returns the registry name and params that build enc, or
+// its type name for encoders the registry doesn't know.
+func encoderConfig(enc Encoder) (string, map[string]string) {
+	switch e := enc.(type) {
+	case nil, TextEncoder:
+		return "text", nil
+	case JSONEncoder:
+		return "json", nil
+	case CombinedEncoder:
+		return "combined", nil
+	case LogfmtEncoder:
+		return "logfmt", nil
+	case ConsoleEncoder:
+		return "console", map[string]string{
+			"color":  strconv.FormatBool(e.Color),
+			"caller": strconv.FormatBool(e.Caller),
+		}
+	case PrefixEncoder:
+		name, params := encoderConfig(e.Encoder)
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params["encoder"] = name
+		for s, prefix := range e.Prefixes {
+			params[s.String()] = prefix
+		}
+		return "prefix", params
+	}
+	return fmt.Sprintf("%T", enc), nil
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type fieldsKey struct{}
+
+type traceKey struct{}
+
+// traceInfo is the trace a ctx belongs to.
+type traceInfo struct {
+	id      string
+	sampled bool
+}
+
+// WithFields 返回附带日志字段的ctx, 通过Ctx系列方法写日志时附加这些字段
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	old, _ := ctx.Value(fieldsKey{}).([]Field)
+	merged := make([]Field, 0, len(old)+len(fields))
+	merged = append(merged, old...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// WithTrace 返回附带trace id及其采样标记的ctx
+func WithTrace(ctx context.Context, traceID string, sampled bool) context.Context {
+	return context.WithValue(ctx, traceKey{}, traceInfo{id: traceID, sampled: sampled})
+}
+
+// contextFields returns the fields attached to ctx, trace id first.
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	if t, ok := ctx.Value(traceKey{}).(traceInfo); ok {
+		fields = append([]Field{{Key: "trace_id", Value: t.id}}, fields...)
+	}
+	return fields
+}
+
+// SetDebugSampledOnly 设置为true时, 属于未采样trace的Debug日志将被丢弃, 使Debug日志与trace覆盖相同的请求
+func (l *Logger) SetDebugSampledOnly(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.debugSampledOnly, v)
+}
+
+// traceAllowed reports whether an entry of severity s logged with ctx
+// passes trace sampling. Entries without a trace are always allowed.
+func (l *Logger) traceAllowed(ctx context.Context, s Severity) bool {
+	if s.atLeast(SeverityInfo) || ctx == nil || atomic.LoadInt32(&l.debugSampledOnly) == 0 {
+		return true
+	}
+	t, ok := ctx.Value(traceKey{}).(traceInfo)
+	return !ok || t.sampled
+}
+
+// SetContextAnnotation 设置Ctx系列方法是否标注ctx状态: ctx已结束时附加ctx_err字段,
+// 距截止时间不足near时附加ctx_deadline_in字段
+func (l *Logger) SetContextAnnotation(enabled bool, near time.Duration) {
+	atomic.StoreInt64(&l.ctxNear, int64(near))
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.ctxAnnotate, v)
+}
+
+// annotateContext appends ctx's cancellation state to fields.
+func (l *Logger) annotateContext(ctx context.Context, fields []Field) []Field {
+	if ctx == nil || atomic.LoadInt32(&l.ctxAnnotate) == 0 {
+		return fields
+	}
+	if err := ctx.Err(); err != nil {
+		return append(fields[:len(fields):len(fields)], Field{Key: "ctx_err", Value: err.Error()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		left := time.Until(deadline)
+		if left < time.Duration(atomic.LoadInt64(&l.ctxNear)) {
+			return append(fields[:len(fields):len(fields)], Field{Key: "ctx_deadline_in", Value: left.String()})
+		}
+	}
+	return fields
+}
+
+func (l *Logger) printlnCtx(ctx context.Context, s Severity, args ...interface{}) {
+	if !l.enabled(s) || !l.traceAllowed(ctx, s) {
+		return
+	}
+	e := l.newEntry(s, 0, fmt.Sprintln(args...))
+	e.Fields = l.annotateContext(ctx, append(e.Fields[:len(e.Fields):len(e.Fields)], contextFields(ctx)...))
+	l.log(e)
+}
+
+func (l *Logger) printfCtx(ctx context.Context, s Severity, format string, args ...interface{}) {
+	if !l.enabled(s) || !l.traceAllowed(ctx, s) {
+		return
+	}
+	e := l.newEntry(s, 0, fmt.Sprintf(format, args...))
+	e.Fields = l.annotateContext(ctx, append(e.Fields[:len(e.Fields):len(e.Fields)], contextFields(ctx)...))
+	l.log(e)
+}
+
+// DebugCtx 写Debug日志, 附加ctx中的字段
+func (l *Logger) DebugCtx(ctx context.Context, args ...interface{}) {
+	l.printlnCtx(ctx, SeverityDebug, args...)
+}
+
+// InfoCtx 写Info日志, 附加ctx中的字段
+func (l *Logger) InfoCtx(ctx context.Context, args ...interface{}) {
+	l.printlnCtx(ctx, SeverityInfo, args...)
+}
+
+// WarningCtx 写Warning日志, 附加ctx中的字段
+func (l *Logger) WarningCtx(ctx context.Context, args ...interface{}) {
+	l.printlnCtx(ctx, SeverityWarning, args...)
+}
+
+// ErrorCtx 写Error日志, 附加ctx中的字段
+func (l *Logger) ErrorCtx(ctx context.Context, args ...interface{}) {
+	l.printlnCtx(ctx, SeverityError, args...)
+}
+
+// DebugCtxf 写格式化Debug日志, 附加ctx中的字段
+func (l *Logger) DebugCtxf(ctx context.Context, format string, args ...interface{}) {
+	l.printfCtx(ctx, SeverityDebug, format, args...)
+}
+
+// InfoCtxf 写格式化Info日志, 附加ctx中的字段
+func (l *Logger) InfoCtxf(ctx context.Context, format string, args ...interface{}) {
+	l.printfCtx(ctx, SeverityInfo, format, args...)
+}
+
+// WarningCtxf 写格式化Warning日志, 附加ctx中的字段
+func (l *Logger) WarningCtxf(ctx context.Context, format string, args ...interface{}) {
+	l.printfCtx(ctx, SeverityWarning, format, args...)
+}
+
+// ErrorCtxf 写格式化Error日志, 附加ctx中的字段
+func (l *Logger) ErrorCtxf(ctx context.Context, format string, args ...interface{}) {
+	l.printfCtx(ctx, SeverityError, format, args...)
+}
+
+// DebugCtx 默认logger快捷调用
+func DebugCtx(ctx context.Context, args ...interface{}) {
+	DefaultLogger.printlnCtx(ctx, SeverityDebug, args...)
+}
+
+// InfoCtx 默认logger快捷调用
+func InfoCtx(ctx context.Context, args ...interface{}) {
+	DefaultLogger.printlnCtx(ctx, SeverityInfo, args...)
+}
+
+// WarningCtx 默认logger快捷调用
+func WarningCtx(ctx context.Context, args ...interface{}) {
+	DefaultLogger.printlnCtx(ctx, SeverityWarning, args...)
+}
+
+// ErrorCtx 默认logger快捷调用
+func ErrorCtx(ctx context.Context, args ...interface{}) {
+	DefaultLogger.printlnCtx(ctx, SeverityError, args...)
+}
+
+// DebugCtxf 默认logger快捷调用
+func DebugCtxf(ctx context.Context, format string, args ...interface{}) {
+	DefaultLogger.printfCtx(ctx, SeverityDebug, format, args...)
+}
+
+// InfoCtxf 默认logger快捷调用
+func InfoCtxf(ctx context.Context, format string, args ...interface{}) {
+	DefaultLogger.printfCtx(ctx, SeverityInfo, format, args...)
+}
+
+// WarningCtxf 默认logger快捷调用
+func WarningCtxf(ctx context.Context, format string, args ...interface{}) {
+	DefaultLogger.printfCtx(ctx, SeverityWarning, format, args...)
+}
+
+// ErrorCtxf 默认logger快捷调用
+func ErrorCtxf(ctx context.Context, format string, args ...interface{}) {
+	DefaultLogger.printfCtx(ctx, SeverityError, format, args...)
+}
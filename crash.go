@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// crashRing keeps the last entries written, for crash reports.
+type crashRing struct {
+	mu      sync.Mutex
+	entries []*Entry
+	next    int
+	full    bool
+}
+
+func (r *crashRing) add(e *Entry) {
+	r.mu.Lock()
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next, r.full = 0, true
+	}
+	r.mu.Unlock()
+}
+
+// list returns the kept entries, oldest first.
+func (r *crashRing) list() []*Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]*Entry(nil), r.entries[:r.next]...)
+	}
+	return append(append([]*Entry(nil), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// SetCrashReport 设置Fatal与Panic时是否在日志目录写出一次性的JSON崩溃报告<name>.crash.<时间>.<pid>.json,
+// 包含最后的消息、调用栈、最近recent条日志与构建信息
+func (l *Logger) SetCrashReport(enabled bool, recent int) {
+	var r *crashRing
+	if enabled {
+		if recent < 1 {
+			recent = 1
+		}
+		r = &crashRing{entries: make([]*Entry, recent+1)} // the final entry takes one
+	}
+	l.crash.Store(r)
+}
+
+func (l *Logger) crashRing() *crashRing {
+	r, _ := l.crash.Load().(*crashRing)
+	return r
+}
+
+// crashEntry is an Entry as written to crash reports.
+type crashEntry struct {
+	Time     time.Time         `json:"time"`
+	Severity string            `json:"level"`
+	Caller   string            `json:"caller,omitempty"`
+	Message  string            `json:"msg"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+func newCrashEntry(e *Entry) crashEntry {
+	ce := crashEntry{Time: e.Time, Severity: e.Severity.name(), Message: e.Message}
+	if e.File != "" {
+		ce.Caller = e.File + ":" + strconv.Itoa(e.Line)
+	}
+	if len(e.Fields) > 0 {
+		ce.Fields = make(map[string]string, len(e.Fields))
+		for _, f := range e.Fields {
+			ce.Fields[f.Key] = f.String()
+		}
+	}
+	return ce
+}
+
+type crashReport struct {
+	Final  crashEntry        `json:"final"`
+	Stack  string            `json:"stack"`
+	Recent []crashEntry      `json:"recent"`
+	Build  map[string]string `json:"build"`
+	Host   string            `json:"host"`
+	Pid    int               `json:"pid"`
+	Args   []string          `json:"args"`
+}
+
+// writeCrashReport writes the crash report for e, the Fatal or panic
+// entry, if crash reports are enabled.
+func (l *Logger) writeCrashReport(e *Entry, stack string) {
+	r := l.crashRing()
+	if r == nil {
+		return
+	}
+	rep := crashReport{
+		Final: newCrashEntry(e),
+		Stack: stack,
+		Build: buildInfo(),
+		Pid:   pid,
+		Args:  os.Args,
+	}
+	rep.Host, _ = os.Hostname()
+	for _, re := range r.list() {
+		if re != e {
+			rep.Recent = append(rep.Recent, newCrashEntry(re))
+		}
+	}
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		l.reportError(err)
+		return
+	}
+	l.mu.Lock()
+	dir, name := l.getLogDir(), l.getLogName()
+	l.mu.Unlock()
+	path := filepath.Join(dir, fmt.Sprintf("%s.crash.%s.%d.json", name, e.Time.Format("20060102-150405"), pid))
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		l.reportError(err)
+	}
+}
+
+// buildInfo describes the running binary.
+func buildInfo() map[string]string {
+	info := map[string]string{
+		"go":   runtime.Version(),
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info["path"] = bi.Path
+		info["version"] = bi.Main.Version
+		if bi.Main.Sum != "" {
+			info["sum"] = bi.Main.Sum
+		}
+	}
+	return info
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// SetWriteDeadline 设置单次日志文件操作的最长耗时, 超时(如NFS或fuse挂载无响应)时报告错误,
+// 之后的日志改写stderr直到该操作返回, 避免所有写日志的goroutine被阻塞; 0表示不检查
+func (l *Logger) SetWriteDeadline(d time.Duration) {
+	atomic.StoreInt64(&l.writeDeadline, int64(d))
+	if d > 0 {
+		l.deadlineOnce.Do(func() { l.daemons.spawn(l.writeWatchdog) })
+	}
+}
+
+// beginWrite marks the start of a file operation that may block.
+// l.mu is held.
+func (l *Logger) beginWrite() {
+	if atomic.LoadInt64(&l.writeDeadline) > 0 {
+		atomic.StoreInt64(&l.writeStart, time.Now().UnixNano())
+	}
+}
+
+// endWrite marks the end of the operation started by beginWrite and
+// leaves the stalled state if the watchdog had entered it.
+// l.mu is held.
+func (l *Logger) endWrite() {
+	start := atomic.SwapInt64(&l.writeStart, 0)
+	if atomic.CompareAndSwapInt32(&l.writeStalled, 1, 0) {
+		l.reportError(fmt.Errorf("log file write returned after %v; file output resumed", time.Since(time.Unix(0, start))))
+	}
+}
+
+// stalled reports whether a file operation has overrun the write deadline.
+func (l *Logger) stalled() bool {
+	return atomic.LoadInt32(&l.writeStalled) != 0
+}
+
+// writeStalledEntry writes buf to stderr without taking l.mu, which the
+// stalled operation is holding.
+func (l *Logger) writeStalledEntry(buf *buffer) {
+	l.stderr.wmu.Lock()
+	os.Stderr.Write(buf.Bytes())
+	l.stderr.wmu.Unlock()
+	_bufferPool.release(buf)
+}
+
+// writeWatchdog checks the pending file operation against the deadline.
+func (l *Logger) writeWatchdog(stop <-chan struct{}) {
+	for {
+		d := time.Duration(atomic.LoadInt64(&l.writeDeadline))
+		interval := d / 4
+		if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+		start := atomic.LoadInt64(&l.writeStart)
+		if d <= 0 || start == 0 || time.Since(time.Unix(0, start)) < d {
+			continue
+		}
+		if atomic.CompareAndSwapInt32(&l.writeStalled, 0, 1) {
+			l.reportError(fmt.Errorf("log file write blocked for more than %v; writing to stderr", d))
+		}
+	}
+}
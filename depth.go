@@ -0,0 +1,52 @@
+package logger
+
+import "fmt"
+
+// printlnDepth is println with depth extra frames skipped when resolving
+// the caller, for wrappers that log on behalf of their own caller.
+func (l *Logger) printlnDepth(s Severity, depth int, args ...interface{}) {
+	if !l.enabled(s) {
+		return
+	}
+	l.log(l.newEntry(s, depth, fmt.Sprintln(args...)))
+}
+
+// DebugDepth 写Debug日志, 调用位置向上跳过depth层栈帧
+func (l *Logger) DebugDepth(depth int, args ...interface{}) {
+	l.printlnDepth(SeverityDebug, depth, args...)
+}
+
+// InfoDepth 写Info日志, 调用位置向上跳过depth层栈帧
+func (l *Logger) InfoDepth(depth int, args ...interface{}) {
+	l.printlnDepth(SeverityInfo, depth, args...)
+}
+
+// WarningDepth 写Warning日志, 调用位置向上跳过depth层栈帧
+func (l *Logger) WarningDepth(depth int, args ...interface{}) {
+	l.printlnDepth(SeverityWarning, depth, args...)
+}
+
+// ErrorDepth 写Error日志, 调用位置向上跳过depth层栈帧
+func (l *Logger) ErrorDepth(depth int, args ...interface{}) {
+	l.printlnDepth(SeverityError, depth, args...)
+}
+
+// DebugDepth 默认logger快捷调用
+func DebugDepth(depth int, args ...interface{}) {
+	DefaultLogger.printlnDepth(SeverityDebug, depth, args...)
+}
+
+// InfoDepth 默认logger快捷调用
+func InfoDepth(depth int, args ...interface{}) {
+	DefaultLogger.printlnDepth(SeverityInfo, depth, args...)
+}
+
+// WarningDepth 默认logger快捷调用
+func WarningDepth(depth int, args ...interface{}) {
+	DefaultLogger.printlnDepth(SeverityWarning, depth, args...)
+}
+
+// ErrorDepth 默认logger快捷调用
+func ErrorDepth(depth int, args ...interface{}) {
+	DefaultLogger.printlnDepth(SeverityError, depth, args...)
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const diskCheckInterval = 10 * time.Second
+
+// SetMinFreeSpace 设置日志所在磁盘的最小剩余空间, 低于该值时从最旧的开始删除未保留的历史日志文件,
+// 仍不足时丢弃Debug/Info日志; 0表示不检查
+func (l *Logger) SetMinFreeSpace(bytes uint64) {
+	atomic.StoreUint64(&l.minFreeSpace, bytes)
+	if bytes > 0 {
+		l.diskOnce.Do(func() { l.daemons.spawn(l.diskWatchdog) })
+	}
+}
+
+// lowDisk reports whether Debug and Info entries are being dropped to save space.
+func (l *Logger) lowDisk() bool {
+	return atomic.LoadInt32(&l.diskLow) != 0
+}
+
+// diskWatchdog periodically checks the free space of the log volume.
+func (l *Logger) diskWatchdog(stop <-chan struct{}) {
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.checkDisk()
+		}
+	}
+}
+
+func (l *Logger) checkDisk() {
+	min := atomic.LoadUint64(&l.minFreeSpace)
+	l.mu.Lock()
+	dir := l.getLogDir()
+	l.mu.Unlock()
+	free, err := freeSpace(dir)
+	if err != nil {
+		return
+	}
+	if min > 0 && free < min {
+		// Make room with old files before dropping new entries.
+		l.mu.Lock()
+		free = l.reclaimSpace(dir, free, min)
+		l.mu.Unlock()
+	}
+	low := min > 0 && free < min
+	if low == l.lowDisk() {
+		return
+	}
+	if low {
+		atomic.StoreInt32(&l.diskLow, 1)
+		l.println(SeverityWarning, fmt.Sprintf("free space on %s is %d bytes, below %d; dropping Debug and Info", dir, free, min))
+	} else {
+		atomic.StoreInt32(&l.diskLow, 0)
+		l.println(SeverityWarning, fmt.Sprintf("free space on %s is %d bytes; Debug and Info resumed", dir, free))
+	}
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Drain 停止接收新日志, 写出异步队列并将缓冲写入所有文件, 返回被丢弃的条目数; ctx到期时同时返回ctx的错误
+func (l *Logger) Drain(ctx context.Context) (int, error) {
+	atomic.StoreInt32(&l.draining, 1)
+	l.asyncMu.Lock()
+	q := l.async
+	l.async = nil
+	l.asyncMu.Unlock()
+	done := make(chan struct{})
+	drain := func(<-chan struct{}) {
+		if q != nil {
+			close(q.ch)
+			<-q.done
+		}
+		l.Flush()
+		close(done)
+	}
+	if !l.daemons.spawn(drain) {
+		drain(nil) // closed: the async writer is gone already
+	}
+	select {
+	case <-done:
+		return l.droppedCount(), nil
+	case <-ctx.Done():
+		if q == nil {
+			return l.droppedCount(), ctx.Err()
+		}
+		atomic.StoreInt32(&q.abandoned, 1)
+		return l.droppedCount() + len(q.ch), ctx.Err()
+	}
+}
+
+func (l *Logger) isDraining() bool {
+	return atomic.LoadInt32(&l.draining) != 0
+}
+
+func (l *Logger) droppedCount() int {
+	return int(atomic.LoadUint64(&l.dropped))
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// namedLoggers maps names given to RegisterLogger to their Logger.
+var namedLoggers = struct {
+	sync.RWMutex
+	m map[string]*Logger
+}{m: make(map[string]*Logger)}
+
+// RegisterLogger 以name登记Logger, 以便ElevateLevel等按名称查找; l为nil时取消登记
+func RegisterLogger(name string, l *Logger) {
+	namedLoggers.Lock()
+	if l == nil {
+		delete(namedLoggers.m, name)
+	} else {
+		namedLoggers.m[name] = l
+	}
+	namedLoggers.Unlock()
+}
+
+// LookupLogger 返回以name登记的Logger, name为空时返回DefaultLogger
+func LookupLogger(name string) *Logger {
+	if name == "" {
+		return &DefaultLogger
+	}
+	namedLoggers.RLock()
+	defer namedLoggers.RUnlock()
+	return namedLoggers.m[name]
+}
+
+// LoggerNames 返回已登记的Logger名称
+func LoggerNames() []string {
+	namedLoggers.RLock()
+	names := make([]string, 0, len(namedLoggers.m))
+	for name := range namedLoggers.m {
+		names = append(names, name)
+	}
+	namedLoggers.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// ElevateLevel 临时将日志级别降低到s, d后自动恢复; 调用restore可提前恢复.
+// 期间级别被另行修改时不再恢复. s不低于当前级别时什么也不做
+func (l *Logger) ElevateLevel(s Severity, d time.Duration) (restore func()) {
+	prev := l.severityLimit.get()
+	if s.atLeast(prev) {
+		return func() {}
+	}
+	l.severityLimit.set(s)
+	l.syncClassLimits()
+	var once sync.Once
+	undo := func() {
+		once.Do(func() {
+			if l.severityLimit.cas(s, prev) {
+				l.syncClassLimits()
+			}
+		})
+	}
+	timer := time.AfterFunc(d, undo)
+	return func() {
+		timer.Stop()
+		undo()
+	}
+}
+
+// BoostSeverity 临时将日志级别降低到s, d后自动恢复, 便于排查故障时不会忘记关闭详细日志;
+// 等同于ElevateLevel, 如BoostSeverity(SeverityDebug, 10*time.Minute)
+func (l *Logger) BoostSeverity(s Severity, d time.Duration) (restore func()) {
+	return l.ElevateLevel(s, d)
+}
+
+// BoostSeverity 默认logger快捷调用
+func BoostSeverity(s Severity, d time.Duration) (restore func()) {
+	return DefaultLogger.ElevateLevel(s, d)
+}
+
+// ElevateLevel 临时降低以name登记的Logger的日志级别, name为空时为DefaultLogger
+func ElevateLevel(name string, s Severity, d time.Duration) (restore func(), err error) {
+	l := LookupLogger(name)
+	if l == nil {
+		return nil, fmt.Errorf("logger: no logger registered as %q", name)
+	}
+	return l.ElevateLevel(s, d), nil
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+)
+
+// Encoder 日志条目编码器, 将条目编码为一行(含结尾换行)写入buf
+type Encoder interface {
+	EncodeEntry(buf *bytes.Buffer, e *Entry)
+}
+
+// TextEncoder 默认文本格式: [mm-dd hh:mm:ss.uuuuuu L file:line] msg key=value,
+// 行头按写出该条目的Logger的设置(SetTimeFormat、SetHeaderYear等)格式化
+type TextEncoder struct{}
+
+// EncodeEntry 实现Encoder
+func (TextEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	l := e.owner
+	if l == nil {
+		l = &DefaultLogger
+	}
+	buf := l.formatHeader(e.Severity, e.Time, e.File, e.Line, e.goid)
+	buf.WriteString(e.Message)
+	writeFields(buf, e.Fields)
+	buf.WriteByte('\n')
+	dst.Write(buf.Bytes())
+	_bufferPool.release(buf)
+}
+
+// encoderHolder lets an Encoder, including nil, be kept in an atomic.Value.
+type encoderHolder struct {
+	enc Encoder
+}
+
+// SetEncoder 设置日志文件的编码器, nil表示默认文本格式
+func (l *Logger) SetEncoder(enc Encoder) {
+	l.encoder.Store(encoderHolder{enc})
+}
+
+func (l *Logger) getEncoder() Encoder {
+	h, _ := l.encoder.Load().(encoderHolder)
+	return h.enc
+}
+
+// encodeWith formats e with enc into a pooled buffer.
+func encodeWith(enc Encoder, e *Entry) *buffer {
+	buf := _bufferPool.getBuffer()
+	enc.EncodeEntry(&buf.Buffer, e)
+	return buf
+}
+
+// bareEncoder is implemented by encoders for formats read by external
+// tools, whose files get no header or rotation markers.
+type bareEncoder interface {
+	bare()
+}
+
+// bareFiles reports whether the log files hold only encoded entries.
+func (l *Logger) bareFiles() bool {
+	_, ok := l.getEncoder().(bareEncoder)
+	return ok
+}
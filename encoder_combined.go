@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Field keys LogAccess records and CombinedEncoder reads.
+const (
+	AccessRemoteKey    = "remote"
+	AccessUserKey      = "user"
+	AccessRequestKey   = "request"
+	AccessStatusKey    = "status"
+	AccessSizeKey      = "size"
+	AccessRefererKey   = "referer"
+	AccessUserAgentKey = "user_agent"
+)
+
+// CombinedEncoder Apache/NCSA combined日志格式, 可直接被GoAccess/awstats等工具读取;
+// 字段取自LogAccess记录的字段, 缺失的字段写为"-"
+type CombinedEncoder struct{}
+
+// EncodeEntry 实现Encoder
+func (CombinedEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	get := func(key string) string {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				if v := f.String(); v != "" {
+					return v
+				}
+			}
+		}
+		return "-"
+	}
+	request := get(AccessRequestKey)
+	if request == "-" {
+		request = e.Message
+	}
+	dst.WriteString(get(AccessRemoteKey))
+	dst.WriteString(" - ")
+	dst.WriteString(get(AccessUserKey))
+	dst.WriteString(" [")
+	dst.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	dst.WriteString("] ")
+	writeCombinedQuoted(dst, request)
+	dst.WriteByte(' ')
+	dst.WriteString(get(AccessStatusKey))
+	dst.WriteByte(' ')
+	dst.WriteString(get(AccessSizeKey))
+	dst.WriteByte(' ')
+	writeCombinedQuoted(dst, get(AccessRefererKey))
+	dst.WriteByte(' ')
+	writeCombinedQuoted(dst, get(AccessUserAgentKey))
+	dst.WriteByte('\n')
+}
+
+// bare keeps headers and rotation markers out of the files, which must hold
+// nothing but access lines.
+func (CombinedEncoder) bare() {}
+
+// writeCombinedQuoted writes s in double quotes, escaping quotes,
+// backslashes and control characters the way Apache does.
+func writeCombinedQuoted(dst *bytes.Buffer, s string) {
+	dst.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			dst.WriteByte('\\')
+			dst.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			dst.WriteString(`\x`)
+			dst.WriteByte("0123456789abcdef"[c>>4])
+			dst.WriteByte("0123456789abcdef"[c&0xf])
+		default:
+			dst.WriteByte(c)
+		}
+	}
+	dst.WriteByte('"')
+}
+
+// LogAccess 以Info级别记录一次HTTP请求, status为响应码, size为响应体字节数
+func (l *Logger) LogAccess(r *http.Request, status, size int) {
+	if !l.enabled(SeverityInfo) {
+		return
+	}
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	user := ""
+	if r.URL != nil && r.URL.User != nil {
+		user = r.URL.User.Username()
+	} else if name, _, ok := r.BasicAuth(); ok {
+		user = name
+	}
+	request := r.Method + " " + r.RequestURI + " " + r.Proto
+	e := l.newEntry(SeverityInfo, 0, request)
+	e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)],
+		Field{Key: AccessRemoteKey, Value: remote},
+		Field{Key: AccessUserKey, Value: user},
+		Field{Key: AccessRequestKey, Value: request},
+		Field{Key: AccessStatusKey, Value: strconv.Itoa(status)},
+		Field{Key: AccessSizeKey, Value: strconv.Itoa(size)},
+		Field{Key: AccessRefererKey, Value: r.Referer()},
+		Field{Key: AccessUserAgentKey, Value: r.UserAgent()},
+	)
+	l.log(e)
+}
+
+func init() {
+	RegisterEncoder("combined", func(map[string]string) (Encoder, error) {
+		return CombinedEncoder{}, nil
+	})
+}
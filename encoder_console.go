@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ANSI colors used by ConsoleEncoder, indexed by Severity.index of the
+// built-in severities; registered ones take the color of Severity.builtin.
+var severityColor = [...]string{
+	"\x1b[2;90m", // Trace
+	"\x1b[90m",   // Debug
+	"\x1b[36m",   // Info
+	"\x1b[33m",   // Warning
+	"\x1b[31m",   // Error
+	"\x1b[1;31m", // Fatal
+}
+
+const colorReset = "\x1b[0m"
+
+// ConsoleEncoder 适合终端的简短格式: hh:mm:ss L msg key=value
+type ConsoleEncoder struct {
+	Color  bool // 按日志等级着色
+	Caller bool // 输出file:line
+}
+
+// EncodeEntry 实现Encoder
+func (c ConsoleEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	s := e.Severity
+	if !s.valid() {
+		s = SeverityInfo
+	}
+	if c.Color {
+		dst.WriteString(severityColor[s.builtin().index()])
+	}
+	dst.WriteString(e.Time.Format("15:04:05"))
+	dst.WriteByte(' ')
+	dst.WriteByte(s.char())
+	if c.Color {
+		dst.WriteString(colorReset)
+	}
+	dst.WriteByte(' ')
+	if c.Caller {
+		dst.WriteString(e.File)
+		dst.WriteByte(':')
+		dst.WriteString(strconv.Itoa(e.Line))
+		dst.WriteString("] ")
+	}
+	dst.WriteString(e.Text())
+	dst.WriteByte('\n')
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// JSONEncoder 每行一个JSON对象: {"time":..,"level":..,"caller":..,"msg":..,<字段>...}, 无调用位置时省略caller
+type JSONEncoder struct{}
+
+// EncodeEntry 实现Encoder
+func (JSONEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	dst.WriteString(`{"time":"`)
+	dst.WriteString(e.Time.Format(time.RFC3339Nano))
+	dst.WriteString(`","level":"`)
+	dst.WriteString(e.Severity.name())
+	dst.WriteByte('"')
+	if e.File != "" {
+		dst.WriteString(`,"caller":`)
+		writeJSONString(dst, e.File+":"+strconv.Itoa(e.Line))
+	}
+	dst.WriteString(`,"msg":`)
+	writeJSONString(dst, e.Message)
+	for _, f := range e.Fields {
+		dst.WriteByte(',')
+		writeJSONString(dst, f.Key)
+		dst.WriteByte(':')
+		writeJSONValue(dst, f.Value)
+	}
+	dst.WriteString("}\n")
+}
+
+func writeJSONString(dst *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	dst.Write(b)
+}
+
+// writeJSONValue writes v as JSON, falling back to its text for values
+// encoding/json can't handle.
+func writeJSONValue(dst *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		writeJSONString(dst, err.Error())
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeJSONString(dst, Field{Value: v}.String())
+		return
+	}
+	dst.Write(b)
+}
+
+func init() {
+	RegisterEncoder("json", func(map[string]string) (Encoder, error) {
+		return JSONEncoder{}, nil
+	})
+}
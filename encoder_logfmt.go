@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogfmtEncoder 每行一组logfmt键值: time=.. level=.. caller=.. msg=.. <字段>..., 无调用位置时省略caller;
+// 供日志收集工具直接解析, 文件不含文件头
+type LogfmtEncoder struct{}
+
+// EncodeEntry 实现Encoder
+func (LogfmtEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	dst.WriteString("time=")
+	dst.WriteString(e.Time.Format(time.RFC3339Nano))
+	dst.WriteString(" level=")
+	dst.WriteString(strings.ToLower(e.Severity.name()))
+	if e.File != "" {
+		dst.WriteString(" caller=")
+		writeLogfmtValue(dst, e.File+":"+strconv.Itoa(e.Line))
+	}
+	dst.WriteString(" msg=")
+	writeLogfmtValue(dst, e.Message)
+	for _, f := range e.Fields {
+		dst.WriteByte(' ')
+		dst.WriteString(f.Key)
+		dst.WriteByte('=')
+		writeLogfmtValue(dst, f.String())
+	}
+	dst.WriteByte('\n')
+}
+
+func (LogfmtEncoder) bare() {}
+
+// writeLogfmtValue writes v, quoted when it is empty or holds spaces,
+// quotes, '=' or control characters, so each entry stays on one line.
+func writeLogfmtValue(dst *bytes.Buffer, v string) {
+	if needsQuote(v) || strings.IndexFunc(v, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0 {
+		dst.WriteString(strconv.Quote(v))
+		return
+	}
+	dst.WriteString(v)
+}
+
+func init() {
+	RegisterEncoder("logfmt", func(map[string]string) (Encoder, error) {
+		return LogfmtEncoder{}, nil
+	})
+}
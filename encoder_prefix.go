@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// PrefixEncoder 在Encoder编码的每条日志前加上其等级对应的字面前缀, 如ERROR前加"!! ", 便于grep与人工浏览混合级别的文件;
+// 只加在日志的第一行, Encoder为nil时使用默认文本格式
+type PrefixEncoder struct {
+	Encoder  Encoder
+	Prefixes map[Severity]string
+}
+
+// EncodeEntry 实现Encoder
+func (p PrefixEncoder) EncodeEntry(dst *bytes.Buffer, e *Entry) {
+	dst.WriteString(p.Prefixes[e.Severity])
+	enc := p.Encoder
+	if enc == nil {
+		enc = TextEncoder{}
+	}
+	enc.EncodeEntry(dst, e)
+}
+
+// newPrefixEncoder builds a PrefixEncoder from config params: "encoder"
+// names the wrapped encoder, severity names map to their prefixes, and
+// the remaining params go to the wrapped encoder.
+func newPrefixEncoder(params map[string]string) (Encoder, error) {
+	p := PrefixEncoder{Prefixes: make(map[Severity]string)}
+	inner := make(map[string]string)
+	for k, v := range params {
+		if k == "encoder" {
+			continue
+		}
+		if s, err := ParseSeverity(k); err == nil {
+			p.Prefixes[s] = v
+			continue
+		}
+		inner[k] = v
+	}
+	if name := params["encoder"]; name != "" {
+		if name == "prefix" {
+			return nil, fmt.Errorf("logger: prefix encoder can't wrap itself")
+		}
+		enc, err := NewEncoder(name, inner)
+		if err != nil {
+			return nil, err
+		}
+		p.Encoder = enc
+	}
+	return p, nil
+}
+
+func init() {
+	RegisterEncoder("prefix", newPrefixEncoder)
+}
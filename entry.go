@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Entry 日志条目
+type Entry struct {
+	Severity Severity
+	Time     time.Time // 调用日志方法的时间
+	File     string
+	Line     int
+	Message  string
+	Fields   []Field
+
+	targeted bool          // selected by cohort sampling or a debug target, bypasses the severity limit
+	done     chan struct{} // set on the marker settle queues; closed instead of writing it
+	pc       uintptr       // the log call, for package overrides; 0 if unknown
+	goid     uint64        // the calling goroutine for the header; 0 if not recorded
+	owner    *Logger       // the Logger writing e, whose header options TextEncoder follows
+}
+
+// newEntry records a log call made depth frames above println/printf's
+// caller. The time is taken here, at call time, so entries queued in async
+// mode keep their real timeline.
+func (l *Logger) newEntry(s Severity, depth int, msg string) *Entry {
+	e := &Entry{
+		Severity: s,
+		Time:     time.Now(),
+		Message:  strings.TrimSuffix(msg, "\n"),
+	}
+	if atomic.LoadInt32(&l.noCaller) == 0 {
+		e.pc, e.File, e.Line = caller(3 + depth)
+	}
+	if atomic.LoadInt32(&l.headerGoid) != 0 {
+		e.goid = goid()
+	}
+	e.Fields = boundFields()
+	return e
+}
+
+// log writes e directly or hands it to the async writer.
+func (l *Logger) log(e *Entry) {
+	if !l.checkSeverity(e.Severity) {
+		return
+	}
+	if l.isDraining() {
+		atomic.AddUint64(&l.dropped, 1)
+		return
+	}
+	if limit, ok := l.packageLevel(e); ok {
+		if !e.Severity.atLeast(limit) {
+			return
+		}
+		// Below the Logger's limit, write it like a targeted entry.
+		e.targeted = !e.Severity.atLeast(l.severityLimit.get())
+	} else if !e.Severity.atLeast(l.severityLimit.get()) {
+		e.targeted = e.targeted || l.inCohort(e) || l.debugTargeted(e)
+		if !e.targeted && !l.sinkWants(e.Severity) {
+			return
+		}
+	}
+	if e.shed() {
+		return
+	}
+	l.promotion.promote(e)
+	l.counters.count(e)
+	if !(memOver() && memPolicy().Sync) && l.enqueue(e) {
+		return
+	}
+	l.write(e)
+}
+
+// write formats e and writes it to the log files.
+func (l *Logger) write(e *Entry) {
+	atomic.AddUint64(&l.written[e.Severity.index()], 1)
+	if r := l.crashRing(); r != nil {
+		r.add(e)
+	}
+	l.firstError.record(e)
+	if r := l.runState(); r != nil && e.Severity.atLeast(SeverityError) {
+		r.noteRunError(e)
+	}
+	if c := l.classLogger(e); c != nil {
+		l.writeClass(c, e)
+		return
+	}
+	if !l.latency.sample() {
+		l.output(e, l.encode(e))
+		return
+	}
+	start := time.Now()
+	l.output(e, l.encode(e))
+	l.latency.record(time.Since(start))
+}
+
+// encode formats e as a single line in a pooled buffer, with the configured
+// encoder or the built-in text format.
+func (l *Logger) encode(e *Entry) *buffer {
+	e.owner = l
+	e = l.compressed(e)
+	if enc := l.getEncoder(); enc != nil {
+		return encodeWith(enc, e)
+	}
+	buf := l.formatHeader(e.Severity, e.Time, e.File, e.Line, e.goid)
+	if atomic.LoadInt32(&l.continuation) != 0 {
+		writeContinued(buf, e.Message, e.Severity.char())
+	} else {
+		buf.WriteString(e.Message)
+	}
+	writeFields(buf, e.Fields)
+	if atomic.LoadInt32(&l.writtenAt) != 0 {
+		buf.WriteString(" written_at=")
+		buf.WriteString(formatTime(l.headerTime(time.Now()), l.timeLayout()))
+	}
+	buf.WriteByte('\n')
+	return buf
+}
+
+// writeContinued writes msg with every continuation line prefixed by the
+// severity char and "| ", so each entry can still be told apart by its first
+// line while stack traces stay readable.
+func writeContinued(buf *buffer, msg string, c byte) {
+	for {
+		i := strings.IndexByte(msg, '\n')
+		if i < 0 {
+			buf.WriteString(msg)
+			return
+		}
+		buf.WriteString(msg[:i+1])
+		buf.WriteByte(c)
+		buf.WriteString("| ")
+		msg = msg[i+1:]
+	}
+}
+
+// SetContinuationPrefix 设置多行日志的后续行是否以"<等级字符>| "开头
+func (l *Logger) SetContinuationPrefix(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.continuation, v)
+}
+
+// SetWrittenAt 设置是否在日志末尾附加实际写出时间written_at, 用于衡量异步模式下的写出延迟
+func (l *Logger) SetWrittenAt(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.writtenAt, v)
+}
+
+// Text 返回消息及字段, 不含头部
+func (e *Entry) Text() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	buf := _bufferPool.getBuffer()
+	buf.WriteString(e.Message)
+	writeFields(buf, e.Fields)
+	text := buf.String()
+	_bufferPool.release(buf)
+	return text
+}
+
+// LogEntry 写入外部构造的日志条目, 保留其时间、调用位置与字段, 用于导入其它来源的日志; 非法等级按Info写入
+func (l *Logger) LogEntry(e *Entry) {
+	if !e.Severity.valid() {
+		e.Severity = SeverityInfo
+	}
+	l.log(e)
+}
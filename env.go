@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigureForEnv 按部署环境应用预设配置:
+// dev: Debug等级, 彩色终端输出, 开发模式(断言失败时退出); staging: Info等级, 文本日志文件; prod: Warning等级, JSON日志文件
+func (l *Logger) ConfigureForEnv(env string) error {
+	switch strings.ToLower(env) {
+	case "dev", "development", "local":
+		l.SetSeverityLimit(SeverityDebug)
+		l.SetEncoder(nil)
+		l.SetStderrEncoder(ConsoleEncoder{Color: true, Caller: true})
+		l.SetDevelopment(true)
+	case "staging", "stage", "test":
+		l.SetSeverityLimit(SeverityInfo)
+		l.SetEncoder(nil)
+		l.SetStderrEncoder(nil)
+		l.SetDevelopment(false)
+	case "prod", "production":
+		l.SetSeverityLimit(SeverityWarning)
+		l.SetEncoder(JSONEncoder{})
+		l.SetStderrEncoder(nil)
+		l.SetDevelopment(false)
+	default:
+		return fmt.Errorf("logger: unknown environment %q", env)
+	}
+	l.SetFileOutput(true)
+	return nil
+}
+
+// ConfigureForEnv 默认logger快捷调用
+func ConfigureForEnv(env string) error {
+	return DefaultLogger.ConfigureForEnv(env)
+}
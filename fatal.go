@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExitCodePolicy 按Fatal日志条目决定进程退出码, 返回0时使用默认退出码1
+type ExitCodePolicy func(e *Entry) int
+
+// ExitCodeByField 返回按字段key的值查codes决定退出码的ExitCodePolicy, 如按"category"字段区分配置错误与断言失败
+func ExitCodeByField(key string, codes map[string]int) ExitCodePolicy {
+	return func(e *Entry) int {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				return codes[f.String()]
+			}
+		}
+		return 0
+	}
+}
+
+// SetExitCodePolicy 设置Fatal的退出码策略, FatalWithCode指定的退出码优先; nil恢复默认
+func (l *Logger) SetExitCodePolicy(p ExitCodePolicy) {
+	l.exitPolicy.Store(p)
+}
+
+// SetExitFunc 设置Fatal日志写出后调用的退出函数, 参数为退出码, 默认为os.Exit; nil恢复默认
+func (l *Logger) SetExitFunc(fn func(code int)) {
+	l.exitFunc.Store(fn)
+}
+
+func (l *Logger) exit(code int) {
+	if fn, _ := l.exitFunc.Load().(func(int)); fn != nil {
+		fn(code)
+		return
+	}
+	os.Exit(code)
+}
+
+// exitCode returns the exit code for the Fatal entry e.
+func (l *Logger) exitCode(e *Entry) int {
+	if p, _ := l.exitPolicy.Load().(ExitCodePolicy); p != nil {
+		if code := p(e); code != 0 {
+			return code
+		}
+	}
+	return 1
+}
+
+// fatal writes msg to every file, flushes everything and exits with code,
+// or the policy's code if code is 0. Queued async entries are written
+// first, leaving the Logger in sync mode.
+func (l *Logger) fatal(depth int, msg string, fields []Field, code int) {
+	e := l.newEntry(SeverityFatal, depth, msg)
+	if len(fields) > 0 {
+		e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], fields...)
+	}
+	if code == 0 {
+		code = l.exitCode(e)
+	}
+	l.SetAsync(0)
+	l.log(e)
+	l.Flush()
+	l.stderr.flush()
+	l.writeCrashReport(e, stack())
+	l.exit(code)
+}
+
+// Fatal 写Fatal日志, 写出并刷新所有日志后退出进程
+func (l *Logger) Fatal(args ...interface{}) {
+	l.fatal(0, fmt.Sprintln(args...), nil, 0)
+}
+
+// Fatalf 写格式化Fatal日志, 写出并刷新所有日志后退出进程
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.fatal(0, fmt.Sprintf(format, args...), nil, 0)
+}
+
+// Fatalw 写带字段的Fatal日志后退出进程, 字段可供ExitCodePolicy区分类别
+func (l *Logger) Fatalw(msg string, fields ...Field) {
+	l.fatal(0, msg, fields, 0)
+}
+
+// FatalWithCode 写Fatal日志, 写出并刷新所有日志后以code退出进程
+func (l *Logger) FatalWithCode(code int, args ...interface{}) {
+	l.fatal(0, fmt.Sprintln(args...), nil, code)
+}
+
+// FatalDepth 写Fatal日志后退出进程, 调用位置向上跳过depth层栈帧
+func (l *Logger) FatalDepth(depth int, args ...interface{}) {
+	l.fatal(depth, fmt.Sprintln(args...), nil, 0)
+}
+
+// Fatal 默认logger快捷调用
+func Fatal(args ...interface{}) {
+	DefaultLogger.fatal(0, fmt.Sprintln(args...), nil, 0)
+}
+
+// Fatalf 默认logger快捷调用
+func Fatalf(format string, args ...interface{}) {
+	DefaultLogger.fatal(0, fmt.Sprintf(format, args...), nil, 0)
+}
+
+// FatalWithCode 默认logger快捷调用
+func FatalWithCode(code int, args ...interface{}) {
+	DefaultLogger.fatal(0, fmt.Sprintln(args...), nil, code)
+}
+
+// FatalDepth 默认logger快捷调用
+func FatalDepth(depth int, args ...interface{}) {
+	DefaultLogger.fatal(depth, fmt.Sprintln(args...), nil, 0)
+}
@@ -0,0 +1,41 @@
+package logger
+
+// SetMaxOpenFiles 设置最多同时打开的日志文件数, 超出时关闭最久未写入的文件, 再次写入时重新打开; 0表示不限制
+func (l *Logger) SetMaxOpenFiles(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxOpenFiles = n
+	l.reserveFile(nil)
+}
+
+// reserveFile closes the least recently used files until another one can be
+// opened for sb within the budget. Closed files keep their path and are
+// reopened on demand.
+// l.mu is held.
+func (l *Logger) reserveFile(sb *syncBuffer) {
+	if l.maxOpenFiles <= 0 {
+		return
+	}
+	limit := l.maxOpenFiles
+	if sb == nil {
+		limit++ // only enforce the budget, nothing is about to open
+	}
+	for {
+		var open int
+		var lru *syncBuffer
+		for _, f := range l.file {
+			o, ok := f.(*syncBuffer)
+			if !ok || o == sb || o.file == nil {
+				continue
+			}
+			open++
+			if lru == nil || o.lastUse.Before(lru.lastUse) {
+				lru = o
+			}
+		}
+		if open < limit || lru == nil {
+			return
+		}
+		lru.close()
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field 日志字段
+type Field struct {
+	Key     string
+	Value   interface{}
+	Privacy Privacy
+}
+
+// F 创建日志字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String returns the field's value as text.
+func (f Field) String() string {
+	switch v := f.Value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(f.Value)
+}
+
+// writeFields appends fields as " key=value" pairs, quoting values that
+// would otherwise be ambiguous.
+func writeFields(buf *buffer, fields []Field) {
+	for _, f := range fields {
+		buf.WriteByte(' ')
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		v := f.String()
+		if needsQuote(v) {
+			buf.WriteString(strconv.Quote(v))
+		} else {
+			buf.WriteString(v)
+		}
+	}
+}
+
+func needsQuote(v string) bool {
+	return v == "" || strings.ContainsAny(v, " \t\r\n\"=")
+}
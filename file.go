@@ -1,13 +1,14 @@
 package logger
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,10 +17,22 @@ import (
 // on disk I/O. The flushDaemon will block instead.
 const bufferSize = 256 * 1024
 
+// smallBufferSize is the default buffer of Warning and above. Those entries
+// are rare, and Error ones are flushed right away anyway, so a full-size
+// buffer would mostly hold memory.
+const smallBufferSize = 16 * 1024
+
 var (
-	pid = os.Getpid()
+	pid  = os.Getpid()
+	host = "unknownhost"
 )
 
+func init() {
+	if h, err := os.Hostname(); err == nil {
+		host = shortHostname(h)
+	}
+}
+
 // shortHostname returns its argument, truncating at the first period.
 // For instance, given "www.google.com" it returns "www".
 func shortHostname(hostname string) string {
@@ -29,55 +42,288 @@ func shortHostname(hostname string) string {
 	return hostname
 }
 
-// syncBuffer joins a bufio.Writer to its underlying file, providing access to the
-// file's Sync method and providing a wrapper for the Write method that provides log
-// file rotation. There are conflicting methods, so the file cannot be embedded.
+// syncBuffer joins a list of pending entry buffers to its underlying file,
+// providing access to the file's Sync method and providing a wrapper for the
+// Write method that provides log file rotation. Entries are kept by reference
+// rather than copied, so an entry teed into several severity files is formatted
+// once and only copied by the kernel when the pending list is written out with
+// a single vectored write.
 // l.mu is held for all its methods.
 type syncBuffer struct {
-	logger *Logger
-	*bufio.Writer
-	file   *os.File
-	sev    Severity
-	nbytes uint64 // The number of bytes written to this file
+	logger       *Logger
+	file         *os.File // nil while closed to stay within the fd budget
+	path         string
+	final        string // name path is renamed to once finished, "" when written in place
+	sev          Severity
+	nbytes       uint64 // The number of bytes written to this file
+	pending      []*buffer
+	pendingBytes int
+	lastUse      time.Time
+	notified     bool // the pre-rotation callback fired for this file
+	lastStamp    time.Time
+	rotateAt     time.Time // zero without time-based rotation
+	headerGen    int32     // logger.headerGen when the file header was written
+	torn         bool      // a failed write left a partial entry at the end of the file
+	used         bool      // an entry was written since the file was opened
 }
 
 func (sb *syncBuffer) Sync() error {
+	if sb.file == nil {
+		return nil
+	}
+	sb.logger.beginWrite()
+	defer sb.logger.endWrite()
 	return sb.file.Sync()
 }
 
 func (sb *syncBuffer) Write(p []byte) (n int, err error) {
-	if sb.nbytes+uint64(len(p)) >= sb.logger.getMaxSize() {
-		sb.rotateFile(time.Now())
+	buf := _bufferPool.getBuffer()
+	buf.Write(p)
+	err = sb.writeBuffer(buf)
+	_bufferPool.release(buf)
+	return len(p), err
+}
+
+// writeBuffer queues a reference to buf, rotating first if the entry would
+// not fit in the current file or the rotation interval has passed. Rotation
+// and queueing both happen under l.mu, and an entry is only ever written as
+// a whole buffer, so no entry is split across files or interleaved with
+// another one.
+func (sb *syncBuffer) writeBuffer(buf *buffer) error {
+	now := time.Now()
+	reason := ""
+	switch {
+	case sb.nbytes+uint64(buf.Len()) >= sb.logger.getMaxSize():
+		reason = rotateSize
+	case !sb.rotateAt.IsZero() && !now.Before(sb.rotateAt):
+		reason = rotateTime
+	case sb.used && sb.logger.idleRotate > 0 && now.Sub(sb.lastUse) >= sb.logger.idleRotate:
+		reason = rotateIdle
+	case sb.headerGen != atomic.LoadInt32(&sb.logger.headerGen) && !sb.logger.bareFiles():
+		reason = rotateFormat
+	}
+	if reason != "" {
+		if err := sb.rotateFile(now, reason); err != nil {
+			return err
+		}
+	}
+	buf.retain()
+	sb.used = true
+	sb.lastUse = now
+	sb.pending = append(sb.pending, buf)
+	sb.pendingBytes += buf.Len()
+	memAcquire(buf.Len())
+	sb.nbytes += uint64(buf.Len())
+	sb.logger.checkRotationNotify(sb)
+	if sb.pendingBytes >= sb.logger.bufferSize(sb.sev) {
+		return sb.Flush()
+	}
+	return nil
+}
+
+// Flush writes the pending entries to the file and releases them.
+func (sb *syncBuffer) Flush() error {
+	if len(sb.pending) == 0 {
+		return nil
+	}
+	sb.logger.beginWrite()
+	err := sb.reopen()
+	if err == nil {
+		bufs := make([][]byte, 0, len(sb.pending)+1)
+		if sb.torn {
+			// Terminate the partial entry so the next one starts on its
+			// own line instead of being glued to it.
+			bufs = append(bufs, newline)
+		}
+		for _, b := range sb.pending {
+			bufs = append(bufs, b.Bytes())
+		}
+		var n int64
+		n, err = writeBuffers(sb.file, bufs)
+		sb.torn = err != nil && !onBoundary(bufs, n)
 	}
-	n, err = sb.Writer.Write(p)
-	sb.nbytes += uint64(n)
+	sb.logger.endWrite()
+	for i, b := range sb.pending {
+		_bufferPool.release(b)
+		sb.pending[i] = nil
+	}
+	sb.pending = sb.pending[:0]
+	memRelease(sb.pendingBytes)
+	sb.pendingBytes = 0
+	return err
+}
+
+var newline = []byte{'\n'}
 
-	return
+// onBoundary reports whether the first n bytes of bufs end between two of
+// them, i.e. no entry was cut short.
+func onBoundary(bufs [][]byte, n int64) bool {
+	for _, b := range bufs {
+		if n <= 0 {
+			return n == 0
+		}
+		n -= int64(len(b))
+	}
+	return n == 0
 }
 
-// rotateFile closes the syncBuffer's file and starts a new one.
-func (sb *syncBuffer) rotateFile(now time.Time) error {
-	if sb.file != nil {
+// Reasons recorded in rotation markers.
+const (
+	rotateSize   = "size"
+	rotateTime   = "time"
+	rotateIdle   = "idle"
+	rotateFormat = "format" // the entry header format changed
+)
+
+// rotateFile closes the syncBuffer's file and starts a new one. When a file
+// is replaced, reason is recorded in a marker entry written to both the
+// closing and the opening file, so the chain of files can be followed.
+func (sb *syncBuffer) rotateFile(now time.Time, reason string) error {
+	oldPath, oldFinal := sb.path, sb.final
+	if oldPath != "" {
 		sb.Flush()
-		sb.file.Close()
+		defer sb.logger.cleanup()
+	}
+	oldFile := sb.file
+	sb.logger.reserveFile(sb)
+	sb.logger.beginWrite()
+	f, path, final, err := sb.create(sb.sev.name(), sb.fileStamp(now))
+	sb.logger.endWrite()
+	if oldPath != "" {
+		if oldFile == nil {
+			oldFile, _ = openFile(oldPath, os.O_WRONLY|os.O_APPEND)
+		}
+		if oldFile != nil {
+			// The old file can't take an entry in a changed header format;
+			// the new file's open marker still links back to it.
+			if err == nil && !sb.logger.bareFiles() && reason != rotateFormat {
+				sb.writeMarker(oldFile, now, "close", "new", finalName(path, final), reason)
+			}
+			oldFile.Close()
+		}
+		if oldFinal != "" {
+			if err := renameFile(oldPath, oldFinal); err != nil {
+				sb.logger.reportError(err)
+			} else {
+				oldPath = oldFinal
+			}
+		}
+		sb.logger.runRotateHook(sb.sev, oldPath)
 	}
-	var err error
-	sb.file, _, err = sb.create(severityName[sb.sev], now)
+	sb.file, sb.path, sb.final = f, path, final
 	sb.nbytes = 0
+	sb.notified = false
+	sb.torn = false
+	sb.used = false
+	sb.lastUse = now
+	sb.rotateAt = sb.logger.nextRotation(now)
+	sb.headerGen = atomic.LoadInt32(&sb.logger.headerGen)
 	if err != nil {
 		return err
 	}
 
-	sb.Writer = bufio.NewWriterSize(sb.file, bufferSize)
+	bare := sb.logger.bareFiles()
+	if !bare {
+		// Write header.
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "Log file created at: %s\n", sb.logger.headerTime(now).Format("2006/01/02 15:04:05"))
+		fmt.Fprintf(&buf, "Binary: Built with %s %s for %s/%s\n", runtime.Compiler, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		fmt.Fprintf(&buf, "Log line format: %s\n", sb.logger.lineFormat())
+		n, err := sb.file.Write(buf.Bytes())
+		sb.nbytes += uint64(n)
+		if err != nil {
+			return err
+		}
+	}
+	if sb.logger.banner {
+		sb.nbytes += uint64(sb.writeBanner(now))
+	}
+	if !bare && oldPath != "" {
+		sb.nbytes += uint64(sb.writeMarker(sb.file, now, "open", "prev", oldPath, reason))
+	}
+	return nil
+}
 
-	// Write header.
-	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "Log file created at: %s\n", now.Format("2006/01/02 15:04:05"))
-	fmt.Fprintf(&buf, "Binary: Built with %s %s for %s/%s\n", runtime.Compiler, runtime.Version(), runtime.GOOS, runtime.GOARCH)
-	fmt.Fprintf(&buf, "Log line format: [mm-dd hh:mm:ss.uuuuuu L file:line] msg\n")
-	n, err := sb.file.Write(buf.Bytes())
-	sb.nbytes += uint64(n)
-	return err
+// fileStamp returns the wall-clock time to put in the name of a new file.
+// Names must never repeat or sort before earlier ones, so when the clock was
+// stepped back (NTP, manual change) or two rotations fall in the same second,
+// the stamp continues one second after the previous one instead.
+func (sb *syncBuffer) fileStamp(now time.Time) time.Time {
+	// Round(0) strips the monotonic reading, which would otherwise hide
+	// wall-clock steps from the comparison.
+	t := now.Round(0).Truncate(time.Second)
+	if !sb.lastStamp.IsZero() && !t.After(sb.lastStamp) {
+		t = sb.lastStamp.Add(time.Second)
+	}
+	sb.lastStamp = t
+	return t
+}
+
+// writeMarker writes a rotation marker entry to f and returns its size.
+func (sb *syncBuffer) writeMarker(f *os.File, now time.Time, event, key, path, reason string) int {
+	e := &Entry{
+		Severity: SeverityInfo,
+		Time:     now,
+		File:     "rotation",
+		Message:  "log file rotated",
+		Fields: []Field{
+			{Key: "event", Value: event},
+			{Key: key, Value: filepath.Base(path)},
+			{Key: "reason", Value: reason},
+		},
+	}
+	buf := sb.logger.encode(e)
+	n, _ := f.Write(buf.Bytes())
+	_bufferPool.release(buf)
+	return n
+}
+
+// close flushes and closes the file; it is reopened on the next flush.
+func (sb *syncBuffer) close() {
+	sb.Flush()
+	if sb.file == nil {
+		return
+	}
+	sb.file.Close()
+	sb.file = nil
+}
+
+// finish closes the file for good, renaming it to its final name.
+func (sb *syncBuffer) finish() {
+	sb.close()
+	if sb.final == "" {
+		return
+	}
+	if err := renameFile(sb.path, sb.final); err != nil {
+		sb.logger.reportError(err)
+		return
+	}
+	if link := sb.logger.linkName(sb.sev.name()); link != "" {
+		updateLink(filepath.Dir(sb.final), filepath.Base(sb.final), link) // ignore err
+	}
+	sb.path, sb.final = sb.final, ""
+}
+
+// finalName returns the name a file at path ends up with.
+func finalName(path, final string) string {
+	if final != "" {
+		return final
+	}
+	return path
+}
+
+// reopen opens the file again after it was closed to save descriptors.
+func (sb *syncBuffer) reopen() error {
+	if sb.file != nil {
+		return nil
+	}
+	sb.logger.reserveFile(sb)
+	f, err := openFile(sb.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	sb.file = f
+	return nil
 }
 
 // logName returns a new log file name containing tag, with start time t, and
@@ -93,26 +339,46 @@ func (sb *syncBuffer) logName(tag string, t time.Time) (name, link string) {
 		t.Minute(),
 		t.Second(),
 		pid)
-	return name, sb.logger.getLogName() + "." + tag
+	return name, sb.logger.linkName(tag)
 }
 
 // create creates a new log file and returns the file and its filename, which
 // contains tag ("INFO", "FATAL", etc.) and t.  If the file is created
 // successfully, create also attempts to update the symlink for that tag, ignoring
-// errors.
-func (sb *syncBuffer) create(tag string, t time.Time) (f *os.File, filename string, err error) {
+// errors. With atomic finalize the file is created under a hidden temporary
+// name and final is the name it is renamed to when finished.
+func (sb *syncBuffer) create(tag string, t time.Time) (f *os.File, filename, final string, err error) {
 	name, link := sb.logName(tag, t)
 
 	dir := sb.logger.getLogDir()
-	os.MkdirAll(dir, 0666)
+	os.MkdirAll(dir, 0755)
+	if sb.logger.atomicFinalize {
+		final = filepath.Join(dir, name)
+		name = "." + name + ".tmp"
+	}
 	fname := filepath.Join(dir, name)
-	f, err = os.OpenFile(fname, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	f, err = openFile(fname, os.O_RDWR|os.O_CREATE|os.O_APPEND)
 	if err == nil {
-		symlink := filepath.Join(dir, link)
-		os.Remove(symlink)        // ignore err
-		os.Symlink(name, symlink) // ignore err
-		return f, fname, nil
+		if link != "" {
+			updateLink(dir, name, link) // ignore err
+		}
+		return f, fname, final, nil
 	}
 
-	return nil, "", err
+	return nil, "", "", err
+}
+
+// probeDir checks that log files can be created in dir, which fails on
+// read-only filesystems such as those of containers and snap packages.
+func probeDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("log dir %s is not usable, logging to stderr only: %v", dir, err)
+	}
+	f, err := ioutil.TempFile(dir, ".probe")
+	if err != nil {
+		return fmt.Errorf("log dir %s is not writable, logging to stderr only: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
 }
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const maxSize = 4096
+	l := &Logger{}
+	l.SetLogDir(dir)
+	l.SetLogName("app")
+	l.SetStderrOutput(false)
+	l.SetErrorHandler(func(err error) { t.Error(err) })
+	l.SetMaxSize(maxSize)
+	const n = 200
+	for i := 0; i < n; i++ {
+		l.Warningf("entry-%03d %s", i, strings.Repeat("x", 80))
+	}
+	current := l.CurrentFiles()[SeverityWarning]
+	l.Close()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "app.WARNING.*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) < 4 {
+		t.Fatalf("%d files for %d bytes of entries at max size %d", len(paths), n*100, maxSize)
+	}
+	// Names sort in the order the files were written, the current one last.
+	sort.Strings(paths)
+	if paths[len(paths)-1] != current {
+		t.Errorf("last file %s, current file %s", paths[len(paths)-1], current)
+	}
+	seen := make(map[string]int)
+	for i, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := string(data)
+		// The close marker goes past the limit; the entries don't.
+		body := text
+		if j := strings.LastIndex(strings.TrimSuffix(body, "\n"), "\n"); j >= 0 && strings.Contains(body[j:], " event=close ") {
+			body = body[:j+1]
+		}
+		if len(body) > maxSize {
+			t.Errorf("%s has %d bytes of entries", filepath.Base(path), len(body))
+		}
+		if !strings.HasPrefix(text, "Log file created at: ") {
+			t.Errorf("%s has no header", filepath.Base(path))
+		}
+		if i > 0 && !strings.Contains(text, " prev="+filepath.Base(paths[i-1])+" ") {
+			t.Errorf("%s doesn't link back to %s", filepath.Base(path), filepath.Base(paths[i-1]))
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if j := strings.Index(line, "entry-"); j >= 0 {
+				seen[line[j:j+len("entry-000")]]++
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		if key := fmt.Sprintf("entry-%03d", i); seen[key] != 1 {
+			t.Errorf("%s written %d times", key, seen[key])
+		}
+	}
+}
+
+func TestFileStamp(t *testing.T) {
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		name string
+		last time.Time
+		now  time.Time
+		want time.Time
+	}{
+		{"first file", time.Time{}, base.Add(600 * time.Millisecond), base},
+		{"later second", base, base.Add(2 * time.Second), base.Add(2 * time.Second)},
+		{"same second", base, base.Add(300 * time.Millisecond), base.Add(time.Second)},
+		{"clock stepped back", base, base.Add(-time.Hour), base.Add(time.Second)},
+	}
+	for _, tt := range tests {
+		sb := &syncBuffer{lastStamp: tt.last}
+		if got := sb.fileStamp(tt.now); !got.Equal(tt.want) {
+			t.Errorf("%s: fileStamp = %v, want %v", tt.name, got, tt.want)
+		}
+		if !sb.lastStamp.Equal(tt.want) {
+			t.Errorf("%s: lastStamp = %v, want %v", tt.name, sb.lastStamp, tt.want)
+		}
+	}
+}
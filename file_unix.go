@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func openFile(name string, flag int) (*os.File, error) {
+	return os.OpenFile(name, flag, 0666)
+}
+
+func removeFile(name string) error {
+	return os.Remove(name)
+}
+
+func renameFile(from, to string) error {
+	return os.Rename(from, to)
+}
+
+// updateLink points the symlink link in dir at the file name.
+func updateLink(dir, name, link string) error {
+	symlink := filepath.Join(dir, link)
+	os.Remove(symlink) // ignore err
+	return os.Symlink(name, symlink)
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// Antivirus scanners and indexers briefly open new and rotated files without
+// sharing, so file operations are retried for a short while before giving up.
+const (
+	fileRetries    = 10
+	fileRetryDelay = 50 * time.Millisecond
+)
+
+const (
+	errorAccessDenied     syscall.Errno = 5
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// isLocked reports whether err means another process holds the file.
+func isLocked(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	} else if le, ok := err.(*os.LinkError); ok {
+		err = le.Err
+	}
+	switch err {
+	case errorAccessDenied, errorSharingViolation, errorLockViolation:
+		return true
+	}
+	return false
+}
+
+// retry runs fn until it succeeds, fails for a reason other than a lock
+// held by another process, or runs out of attempts.
+func retry(fn func() error) error {
+	var err error
+	for i := 0; i < fileRetries; i++ {
+		if err = fn(); err == nil || !isLocked(err) {
+			return err
+		}
+		time.Sleep(fileRetryDelay)
+	}
+	return err
+}
+
+func openFile(name string, flag int) (f *os.File, err error) {
+	err = retry(func() error {
+		f, err = os.OpenFile(name, flag, 0666)
+		return err
+	})
+	return f, err
+}
+
+func removeFile(name string) error {
+	return retry(func() error { return os.Remove(name) })
+}
+
+func renameFile(from, to string) error {
+	return retry(func() error { return os.Rename(from, to) })
+}
+
+// updateLink does nothing on Windows, where creating symlinks needs
+// privileges services usually don't have.
+func updateLink(dir, name, link string) error {
+	return nil
+}
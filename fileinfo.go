@@ -0,0 +1,39 @@
+package logger
+
+// FileInfo 返回日志等级s当前写入的文件路径、已写入大小和文件大小上限
+func (l *Logger) FileInfo(s Severity) (path string, size, maxSize uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	maxSize = l.getMaxSize()
+	if !s.valid() {
+		return "", 0, maxSize
+	}
+	if sb, ok := l.file[s.index()].(*syncBuffer); ok {
+		return sb.path, sb.nbytes, maxSize
+	}
+	return "", 0, maxSize
+}
+
+// SetRotationNotify 设置文件写到上限的percent%时调用fn, 每个文件只调用一次, 以便在切换前准备归档;
+// fn在单独的goroutine中执行, fn为nil时关闭
+func (l *Logger) SetRotationNotify(percent int, fn func(s Severity, path string)) {
+	l.mu.Lock()
+	l.notifyPercent = percent
+	l.notifyFunc = fn
+	l.mu.Unlock()
+}
+
+// checkRotationNotify fires the pre-rotation callback once sb has grown past
+// the configured share of maxSize.
+// l.mu is held.
+func (l *Logger) checkRotationNotify(sb *syncBuffer) {
+	if l.notifyFunc == nil || sb.notified {
+		return
+	}
+	if sb.nbytes*100 < l.getMaxSize()*uint64(l.notifyPercent) {
+		return
+	}
+	sb.notified = true
+	fn, s, path := l.notifyFunc, sb.sev, sb.path
+	l.daemons.spawn(func(<-chan struct{}) { fn(s, path) })
+}
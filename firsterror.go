@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// firstError keeps the first Error or higher entry written.
+type firstError struct {
+	set int32 // fast path once an entry is kept
+	mu  sync.Mutex
+	e   Entry
+}
+
+// record keeps e if it is the first error since start or reset.
+func (f *firstError) record(e *Entry) {
+	if atomic.LoadInt32(&f.set) != 0 || !e.Severity.atLeast(SeverityError) {
+		return
+	}
+	f.mu.Lock()
+	if f.set == 0 {
+		f.e = *e
+		atomic.StoreInt32(&f.set, 1)
+	}
+	f.mu.Unlock()
+}
+
+// FirstError 返回启动或ResetFirstError以来写出的第一条Error及以上级别的日志, 供健康检查报告根因
+func (l *Logger) FirstError() (Entry, bool) {
+	l.firstError.mu.Lock()
+	defer l.firstError.mu.Unlock()
+	return l.firstError.e, l.firstError.set != 0
+}
+
+// ResetFirstError 清除FirstError记录的日志, 重新等待下一条错误
+func (l *Logger) ResetFirstError() {
+	l.firstError.mu.Lock()
+	l.firstError.e = Entry{}
+	atomic.StoreInt32(&l.firstError.set, 0)
+	l.firstError.mu.Unlock()
+}
+
+// FirstError 默认logger快捷调用
+func FirstError() (Entry, bool) {
+	return DefaultLogger.FirstError()
+}
@@ -0,0 +1,388 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxFrameSize bounds a forwarded entry, so a corrupt length can't make
+// the receiver allocate without limit.
+const maxFrameSize = 16 << 20
+
+// A frame is the magic, the payload length and its CRC-32C, both big
+// endian, then the JSON payload. The magic and checksum let the receiver
+// drop partial or corrupted frames and find the next good one.
+const frameHeaderSize = 10
+
+var (
+	frameMagic = [2]byte{'v', 'g'}
+	crcTable   = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// forwardedEntry is the wire form of an Entry. Field values travel as text.
+type forwardedEntry struct {
+	Severity int32          `json:"s"` // a number, so custom severities survive receivers that lack their names
+	Time     int64          `json:"t"`
+	File     string         `json:"f,omitempty"`
+	Line     int            `json:"l,omitempty"`
+	Message  string         `json:"m"`
+	Fields   []forwardField `json:"d,omitempty"`
+}
+
+type forwardField struct {
+	Key     string  `json:"k"`
+	Value   string  `json:"v"`
+	Privacy Privacy `json:"p,omitempty"`
+}
+
+// ForwardSink 把日志条目按帧(2字节魔数+4字节长度+4字节CRC-32C+JSON)写给父进程或远端, 由接收方的ReceiveForwarded写入其日志文件
+type ForwardSink struct {
+	mu    sync.Mutex
+	w     *bufio.Writer
+	codec *forwardCodec // nil on uncompressed streams
+	block []byte        // frames not yet compressed into a block
+	c     io.Closer     // the connection opened by DialForward
+}
+
+// NewForwardSink 创建写往w的ForwardSink
+func NewForwardSink(w io.Writer) *ForwardSink {
+	return &ForwardSink{w: bufio.NewWriter(w)}
+}
+
+// WriteEntry 实现Sink
+func (fs *ForwardSink) WriteEntry(e *Entry) error {
+	fe := forwardedEntry{
+		Severity: int32(e.Severity),
+		Time:     e.Time.UnixNano(),
+		File:     e.File,
+		Line:     e.Line,
+		Message:  e.Message,
+	}
+	for _, f := range e.Fields {
+		fe.Fields = append(fe.Fields, forwardField{Key: f.Key, Value: f.String(), Privacy: f.Privacy})
+	}
+	payload, err := json.Marshal(fe)
+	if err != nil {
+		return err
+	}
+	hdr := frameHeader(frameMagic, payload)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.codec != nil {
+		fs.block = append(append(fs.block, hdr[:]...), payload...)
+		if len(fs.block) >= forwardBlockSize {
+			err = fs.writeBlock()
+		}
+	} else {
+		fs.w.Write(hdr[:])
+		_, err = fs.w.Write(payload)
+	}
+	if err == nil && e.Severity.atLeast(SeverityError) {
+		err = fs.flush()
+	}
+	return err
+}
+
+// Flush 实现Sink
+func (fs *ForwardSink) Flush() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.flush()
+}
+
+// flush writes the buffered frames to the stream, compressing them first
+// on compressed streams.
+// fs.mu is held.
+func (fs *ForwardSink) flush() error {
+	if fs.codec != nil {
+		if err := fs.writeBlock(); err != nil {
+			return err
+		}
+	}
+	return fs.w.Flush()
+}
+
+// frameHeader returns the header of a frame with the magic and payload.
+func frameHeader(magic [2]byte, payload []byte) [frameHeaderSize]byte {
+	var hdr [frameHeaderSize]byte
+	copy(hdr[:], magic[:])
+	binary.BigEndian.PutUint32(hdr[2:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(hdr[6:], crc32.Checksum(payload, crcTable))
+	return hdr
+}
+
+// ForwardTo 切换为转发模式: 不再写日志文件, 所有日志经w转发给父进程(如子进程的stdout或cmd.ExtraFiles中的管道),
+// 轮转与配置由父进程决定
+func (l *Logger) ForwardTo(w io.Writer) *ForwardSink {
+	fs := NewForwardSink(w)
+	l.SetFileOutput(false)
+	l.mu.Lock()
+	l.noStderr = true
+	l.mu.Unlock()
+	l.AddSink(fs)
+	return fs
+}
+
+// ReceiveForwarded 读取ForwardSink转发的日志(如子进程的管道或TCP连接)并写入本Logger, 保留原时间和调用位置,
+// 非空的source作为source字段附加; 校验失败的帧被跳过并重新同步到下一帧, 经错误回调报告; 读到EOF时返回nil
+func (l *Logger) ReceiveForwarded(r io.Reader, source string) error {
+	return l.receiveForwarded(r, nil, source)
+}
+
+// receiveForwarded reads the stream from r, answering an offer of codecs
+// on w.
+func (l *Logger) receiveForwarded(r io.Reader, w io.Writer, source string) error {
+	br := bufio.NewReader(r)
+	codec, err := openForwardStream(br, w)
+	if err != nil {
+		return err
+	}
+	rc := &forwardReceiver{l: l, source: source}
+	if codec == nil {
+		return rc.receive(newFrameReader(br, frameMagic), rc.entry)
+	}
+	decode, release := codec.decoder()
+	defer release()
+	return rc.receive(newFrameReader(br, blockMagic), func(block []byte) bool {
+		frames, err := decode(block)
+		if err != nil {
+			return false
+		}
+		rc.receive(newFrameReader(bytes.NewReader(frames), frameMagic), rc.entry)
+		return true
+	})
+}
+
+// forwardReceiver writes the entries of a forwarded stream to l.
+type forwardReceiver struct {
+	l       *Logger
+	source  string
+	skipped int // bytes of bad frames not reported yet
+}
+
+// receive passes the payload of each frame of fr to handle, which reports
+// whether it was good, until fr ends.
+func (rc *forwardReceiver) receive(fr *frameReader, handle func(payload []byte) bool) error {
+	for {
+		if !fr.fill(frameHeaderSize) {
+			if rc.l.isDraining() {
+				return fr.err // Close cut the stream short
+			}
+			rc.skipped += fr.end - fr.off
+			rc.report()
+			if fr.err == io.EOF {
+				return nil
+			}
+			return fr.err
+		}
+		payload, ok := fr.frame()
+		if !ok {
+			rc.skipped += fr.resync()
+		} else if !handle(payload) {
+			// The frame itself was whole and is consumed; only its
+			// content was bad, so the next frame follows directly.
+			rc.skipped += frameHeaderSize + len(payload)
+		}
+	}
+}
+
+// entry logs the entry in payload, reporting false if it isn't one.
+func (rc *forwardReceiver) entry(payload []byte) bool {
+	var fe forwardedEntry
+	if json.Unmarshal(payload, &fe) != nil {
+		return false
+	}
+	rc.report()
+	rc.l.log(fe.entry(rc.source))
+	return true
+}
+
+// report reports the bad frames skipped since the last good one.
+func (rc *forwardReceiver) report() {
+	if rc.skipped > 0 {
+		rc.l.reportError(fmt.Errorf("logger: skipped %d bytes of corrupt forwarded frames from %q", rc.skipped, rc.source))
+		rc.skipped = 0
+	}
+}
+
+// frameReader buffers a forwarded stream so that a bad frame can be
+// skipped by scanning the bytes already read for the next magic.
+type frameReader struct {
+	r        io.Reader
+	magic    [2]byte
+	buf      []byte
+	off, end int // the unread bytes are buf[off:end]
+	err      error
+}
+
+func newFrameReader(r io.Reader, magic [2]byte) *frameReader {
+	return &frameReader{r: r, magic: magic, buf: make([]byte, 64*1024)}
+}
+
+// more reads once from r, making room in buf first.
+func (fr *frameReader) more() {
+	if fr.off > 0 {
+		copy(fr.buf, fr.buf[fr.off:fr.end])
+		fr.end -= fr.off
+		fr.off = 0
+	}
+	if fr.end == len(fr.buf) {
+		buf := make([]byte, 2*len(fr.buf))
+		copy(buf, fr.buf[:fr.end])
+		fr.buf = buf
+	}
+	n, err := fr.r.Read(fr.buf[fr.end:])
+	fr.end += n
+	if err != nil {
+		fr.err = err
+	}
+}
+
+// fill reads until n bytes are buffered, reporting whether they are.
+func (fr *frameReader) fill(n int) bool {
+	for fr.end-fr.off < n && fr.err == nil {
+		fr.more()
+	}
+	return fr.end-fr.off >= n
+}
+
+// frame returns the payload of the frame at the start of the buffer and
+// consumes it, or reports false if the frame is bad. While a frame is
+// incomplete, a good frame arriving after its start shows its length was
+// corrupt, so a bad length doesn't stall the stream until that many
+// bytes came.
+func (fr *frameReader) frame() ([]byte, bool) {
+	hdr := fr.buf[fr.off:fr.end]
+	n := binary.BigEndian.Uint32(hdr[2:])
+	if hdr[0] != fr.magic[0] || hdr[1] != fr.magic[1] || n > maxFrameSize {
+		return nil, false
+	}
+	size := frameHeaderSize + int(n)
+	next := 1 // where to look for a later frame, relative to off
+	for fr.end-fr.off < size {
+		if next = fr.scan(next, size); next < 0 || fr.err != nil {
+			return nil, false // cut short by the sender if fr.err is set
+		}
+		fr.more()
+	}
+	frame := fr.buf[fr.off : fr.off+size]
+	if crc32.Checksum(frame[frameHeaderSize:], crcTable) != binary.BigEndian.Uint32(frame[6:]) {
+		return nil, false
+	}
+	fr.off += size
+	return frame[frameHeaderSize:], true
+}
+
+// scan looks for a good, complete frame in the buffer from from up to
+// limit. It returns -1 if there is one, otherwise where to continue the
+// search once more bytes arrive.
+func (fr *frameReader) scan(from, limit int) int {
+	b := fr.buf[fr.off:fr.end]
+	if limit > len(b) {
+		limit = len(b)
+	}
+	for from < limit {
+		i := bytes.IndexByte(b[from:limit], fr.magic[0])
+		if i < 0 {
+			return limit
+		}
+		p := from + i
+		if len(b) < p+frameHeaderSize {
+			return p
+		}
+		n := binary.BigEndian.Uint32(b[p+2:])
+		if b[p+1] != fr.magic[1] || n > maxFrameSize {
+			from = p + 1
+			continue
+		}
+		if len(b) < p+frameHeaderSize+int(n) {
+			return p // wait until it is complete
+		}
+		if crc32.Checksum(b[p+frameHeaderSize:p+frameHeaderSize+int(n)], crcTable) == binary.BigEndian.Uint32(b[p+6:]) {
+			return -1
+		}
+		from = p + 1
+	}
+	return from
+}
+
+// resync drops the bad frame's first byte and the bytes up to the next
+// possible magic, returning how many were dropped.
+func (fr *frameReader) resync() int {
+	b := fr.buf[fr.off+1 : fr.end]
+	skip := 1 + len(b)
+	if i := bytes.IndexByte(b, fr.magic[0]); i >= 0 {
+		skip = 1 + i
+	}
+	fr.off += skip
+	return skip
+}
+
+// entry converts fe back into an Entry.
+func (fe *forwardedEntry) entry(source string) *Entry {
+	e := &Entry{
+		Severity: Severity(fe.Severity),
+		Time:     time.Unix(0, fe.Time),
+		File:     fe.File,
+		Line:     fe.Line,
+		Message:  fe.Message,
+	}
+	if !e.Severity.valid() {
+		e.Severity = SeverityInfo
+	}
+	if source != "" {
+		e.Fields = append(e.Fields, Field{Key: "source", Value: source})
+	}
+	for _, f := range fe.Fields {
+		e.Fields = append(e.Fields, Field{Key: f.Key, Value: f.Value, Privacy: f.Privacy})
+	}
+	return e
+}
+
+// ServeForwarded 接受ln上的连接, 将每个连接转发来的日志写入本Logger, source字段为对端地址; ln关闭时返回.
+// Close关闭仍在接收的连接, 之后到来的连接被直接关闭
+func (l *Logger) ServeForwarded(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		l.serveForwardedConn(conn)
+	}
+}
+
+// serveForwardedConn receives conn in a daemon, with another one closing
+// conn when the Logger closes to end the blocked read.
+func (l *Logger) serveForwardedConn(conn net.Conn) {
+	done := make(chan struct{})
+	watch := func(stop <-chan struct{}) {
+		select {
+		case <-stop:
+			conn.Close()
+		case <-done:
+		}
+	}
+	receive := func(<-chan struct{}) {
+		defer close(done)
+		defer conn.Close()
+		if err := l.receiveForwarded(conn, conn, conn.RemoteAddr().String()); err != nil && !l.isDraining() {
+			l.reportError(err)
+		}
+	}
+	if !l.daemons.spawn(watch) {
+		conn.Close()
+		return
+	}
+	if !l.daemons.spawn(receive) {
+		conn.Close()
+		close(done)
+	}
+}
@@ -0,0 +1,329 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ForwardCompression 转发连接的压缩方式
+type ForwardCompression int
+
+// 转发连接的压缩方式
+const (
+	CompressNone   ForwardCompression = iota // 不压缩
+	CompressFlate                            // flate压缩
+	CompressAuto                             // 对端为回环或私有地址时不压缩, 否则与对端协商压缩方式
+	CompressSnappy                           // snappy压缩, CPU开销最小
+	CompressZstd                             // zstd压缩, 压缩率最高, 适合带宽受限的广域网
+)
+
+// A compressed stream starts with streamMagic followed by the codec byte,
+// then carries blocks: frames with blockMagic whose payload is a whole
+// number of entry frames compressed together. Each block decompresses on
+// its own, so a corrupt block is skipped like a corrupt frame.
+// Uncompressed streams start directly with a frame, whose magic differs.
+//
+// DialForward first offers the codecs it wants with streamMagic, helloByte,
+// their count and the codec bytes; the receiver answers with the codec
+// byte it picked, or noCodec, and the stream follows.
+const (
+	streamMagic = "VGF"
+	helloByte   = '?'
+	noCodec     = 0
+)
+
+var blockMagic = [2]byte{'v', 'z'}
+
+// forwardBlockSize is how many bytes of frames a block collects before it
+// is compressed and written.
+const forwardBlockSize = 64 << 10
+
+// handshakeTimeout bounds the wait for the receiver's answer to an offer.
+const handshakeTimeout = 10 * time.Second
+
+// forwardCodec compresses the blocks of a stream.
+type forwardCodec struct {
+	id     byte
+	encode func(src []byte) []byte
+	// decoder returns the function decompressing the blocks of one
+	// stream, and the one releasing it once the stream ends.
+	decoder func() (decode func(src []byte) ([]byte, error), release func())
+}
+
+var (
+	flateWriters = sync.Pool{New: func() interface{} {
+		zw, _ := flate.NewWriter(nil, flate.BestSpeed)
+		return zw
+	}}
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+)
+
+// zstdEncoder returns the shared zstd encoder, created on first use.
+func zstdEncoder() *zstd.Encoder {
+	zstdOnce.Do(func() {
+		zstdEnc, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	})
+	return zstdEnc
+}
+
+// statelessDecoder returns a decoder that needs no release.
+func statelessDecoder(decode func(src []byte) ([]byte, error)) func() (func(src []byte) ([]byte, error), func()) {
+	return func() (func(src []byte) ([]byte, error), func()) {
+		return decode, func() {}
+	}
+}
+
+// forwardCodecs are the codecs a receiver accepts, the one it prefers
+// first.
+var forwardCodecs = []*forwardCodec{
+	{
+		id: 'z',
+		encode: func(src []byte) []byte {
+			return zstdEncoder().EncodeAll(src, nil)
+		},
+		// A zstd decoder runs goroutines until it is closed.
+		decoder: func() (func(src []byte) ([]byte, error), func()) {
+			dec, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(2*maxFrameSize))
+			return func(src []byte) ([]byte, error) { return dec.DecodeAll(src, nil) }, dec.Close
+		},
+	},
+	{
+		id:      's',
+		encode:  func(src []byte) []byte { return snappy.Encode(nil, src) },
+		decoder: statelessDecoder(func(src []byte) ([]byte, error) { return snappy.Decode(nil, src) }),
+	},
+	{
+		id: 'f',
+		encode: func(src []byte) []byte {
+			var z bytes.Buffer
+			zw := flateWriters.Get().(*flate.Writer)
+			zw.Reset(&z)
+			zw.Write(src)
+			zw.Close()
+			flateWriters.Put(zw)
+			return z.Bytes()
+		},
+		decoder: statelessDecoder(func(src []byte) ([]byte, error) {
+			return ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(src)), 2*maxFrameSize))
+		}),
+	},
+}
+
+// codecByID returns the codec with the id, nil if there is none.
+func codecByID(id byte) *forwardCodec {
+	for _, c := range forwardCodecs {
+		if c.id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// offers returns the ids of the codecs to offer for c, in the order the
+// receiver should prefer them.
+func (c ForwardCompression) offers() []byte {
+	switch c {
+	case CompressFlate:
+		return []byte{'f'}
+	case CompressSnappy:
+		return []byte{'s'}
+	case CompressZstd:
+		return []byte{'z'}
+	case CompressAuto:
+		return []byte{'z', 's', 'f'}
+	}
+	return nil
+}
+
+// NewCompressedForwardSink 创建写往w的压缩ForwardSink, 用于无法协商的单向流(如管道);
+// compression为CompressAuto时使用zstd, CompressNone时不压缩; 接收方的ReceiveForwarded自动识别压缩流
+func NewCompressedForwardSink(w io.Writer, compression ForwardCompression) (*ForwardSink, error) {
+	offers := compression.offers()
+	if len(offers) == 0 {
+		return NewForwardSink(w), nil
+	}
+	return newCodecForwardSink(w, offers[0])
+}
+
+// newCodecForwardSink announces the codec with id on w and returns the
+// sink compressing with it, or an uncompressed sink for noCodec.
+func newCodecForwardSink(w io.Writer, id byte) (*ForwardSink, error) {
+	fs := NewForwardSink(w)
+	if id == noCodec {
+		return fs, nil
+	}
+	if _, err := fs.w.WriteString(streamMagic + string(id)); err != nil {
+		return nil, err
+	}
+	fs.codec = codecByID(id)
+	return fs, nil
+}
+
+// DialForward 连接addr上的ServeForwarded, 返回写往该连接的ForwardSink, 用完后调用Close;
+// 需要压缩时先与对端协商, 对端不支持所提供的压缩方式时不压缩
+func DialForward(addr string, compression ForwardCompression) (*ForwardSink, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if compression == CompressAuto {
+		if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok && isLocalIP(tcp.IP) {
+			compression = CompressNone
+		}
+	}
+	id := byte(noCodec)
+	if offers := compression.offers(); len(offers) > 0 {
+		id, err = offerCodecs(conn, offers)
+	}
+	var fs *ForwardSink
+	if err == nil {
+		fs, err = newCodecForwardSink(conn, id)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	fs.c = conn
+	return fs, nil
+}
+
+// offerCodecs offers the codecs with the ids on conn and returns the one
+// the receiver picked.
+func offerCodecs(conn net.Conn, ids []byte) (byte, error) {
+	hello := append([]byte(streamMagic+string(helloByte)), byte(len(ids)))
+	if _, err := conn.Write(append(hello, ids...)); err != nil {
+		return 0, err
+	}
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	var answer [1]byte
+	if _, err := io.ReadFull(conn, answer[:]); err != nil {
+		return 0, fmt.Errorf("logger: forward compression handshake: %v", err)
+	}
+	id := answer[0]
+	if id != noCodec && (bytes.IndexByte(ids, id) < 0 || codecByID(id) == nil) {
+		return 0, fmt.Errorf("logger: forward receiver picked codec %q that wasn't offered", id)
+	}
+	return id, nil
+}
+
+// isLocalIP reports whether ip is loopback, link-local or in a private
+// range, where bandwidth is cheap enough to skip compression.
+func isLocalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4[0] == 10 || ip4[0] == 172 && ip4[1]&0xf0 == 16 || ip4[0] == 192 && ip4[1] == 168
+	}
+	return len(ip) == net.IPv6len && ip[0]&0xfe == 0xfc
+}
+
+// Close 写出缓冲的日志, 关闭DialForward打开的连接
+func (fs *ForwardSink) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	err := fs.flush()
+	if fs.c != nil {
+		if cerr := fs.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// writeBlock compresses the collected frames into a block.
+// fs.mu is held.
+func (fs *ForwardSink) writeBlock() error {
+	if len(fs.block) == 0 {
+		return nil
+	}
+	z := fs.codec.encode(fs.block)
+	fs.block = fs.block[:0]
+	hdr := frameHeader(blockMagic, z)
+	fs.w.Write(hdr[:])
+	_, err := fs.w.Write(z)
+	return err
+}
+
+// openForwardStream reads the start of the stream in br and returns its
+// codec, nil if it is uncompressed. An offer of codecs is answered on w;
+// without w, only streams announcing their codec can be compressed.
+func openForwardStream(br *bufio.Reader, w io.Writer) (*forwardCodec, error) {
+	hdr, _ := br.Peek(len(streamMagic) + 1)
+	if len(hdr) < len(streamMagic)+1 || string(hdr[:len(streamMagic)]) != streamMagic {
+		return nil, nil
+	}
+	id := hdr[len(streamMagic)]
+	if id != helloByte {
+		br.Discard(len(hdr))
+		if c := codecByID(id); c != nil {
+			return c, nil
+		}
+		return nil, fmt.Errorf("logger: unknown forwarded stream codec %q", id)
+	}
+	if w == nil {
+		return nil, fmt.Errorf("logger: forwarded stream offers compression on a one-way stream")
+	}
+	hello, err := br.Peek(len(streamMagic) + 2)
+	if err != nil {
+		return nil, err
+	}
+	n := len(hello)
+	hello, err = br.Peek(n + int(hello[n-1]))
+	if err != nil {
+		return nil, err
+	}
+	br.Discard(len(hello))
+	answer := byte(noCodec)
+	for _, c := range forwardCodecs {
+		if bytes.IndexByte(hello[n:], c.id) >= 0 {
+			answer = c.id
+			break
+		}
+	}
+	if _, err := w.Write([]byte{answer}); err != nil {
+		return nil, err
+	}
+	return openForwardStream(br, nil)
+}
+
+// newForwardSinkFromParams builds the "forward" sink of a config: params
+// "addr" is the ServeForwarded address, "compression" one of none, flate,
+// snappy, zstd or auto (the default).
+func newForwardSinkFromParams(params map[string]string) (Sink, error) {
+	addr := params["addr"]
+	if addr == "" {
+		return nil, fmt.Errorf("logger: forward sink needs an addr")
+	}
+	compression := CompressAuto
+	switch params["compression"] {
+	case "", "auto":
+	case "none":
+		compression = CompressNone
+	case "flate":
+		compression = CompressFlate
+	case "snappy":
+		compression = CompressSnappy
+	case "zstd":
+		compression = CompressZstd
+	default:
+		return nil, fmt.Errorf("logger: unknown forward compression %q", params["compression"])
+	}
+	return DialForward(addr, compression)
+}
+
+func init() {
+	RegisterSink("forward", newForwardSinkFromParams)
+}
@@ -0,0 +1,416 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// entrySink keeps copies of the entries written to it.
+type entrySink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *entrySink) WriteEntry(e *Entry) error {
+	c := *e
+	c.Fields = append([]Field(nil), e.Fields...)
+	s.mu.Lock()
+	s.entries = append(s.entries, c)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *entrySink) Flush() error { return nil }
+
+func (s *entrySink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var msgs []string
+	for _, e := range s.entries {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// newForwardReceiver returns a Logger recording what it receives, and
+// the errors it reports.
+func newForwardReceiver(t *testing.T) (*Logger, *entrySink, *[]error) {
+	l := &Logger{}
+	t.Cleanup(func() { l.Close() })
+	l.SetFileOutput(false)
+	l.SetStderrOutput(false)
+	l.SetSeverityLimit(SeverityTrace)
+	s := &entrySink{}
+	l.AddSink(s)
+	errs := new([]error)
+	l.SetErrorHandler(func(err error) { *errs = append(*errs, err) })
+	return l, s, errs
+}
+
+func TestForwardRoundTrip(t *testing.T) {
+	notice := testSeverity(t, "NOTICE", 'N', SeverityInfo)
+	tests := []struct {
+		name        string
+		compression ForwardCompression
+		magic       string // how the stream starts
+	}{
+		{"none", CompressNone, "vg"},
+		{"flate", CompressFlate, "VGFf"},
+		{"snappy", CompressSnappy, "VGFs"},
+		{"zstd", CompressZstd, "VGFz"},
+		{"auto", CompressAuto, "VGFz"},
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 6000, time.Local)
+	sent := []*Entry{
+		{Severity: SeverityDebug, Time: at, File: "a.go", Line: 1, Message: "first"},
+		{Severity: notice, Time: at, File: "b.go", Line: 2, Message: "custom severity"},
+		{Severity: SeverityError, Time: at, Message: "fields", Fields: []Field{{Key: "user", Value: 42}, {Key: "ip", Value: "1.2.3.4", Privacy: PrivacySensitive}}},
+		{Severity: SeverityInfo, Time: at, Message: strings.Repeat("long ", 20000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream bytes.Buffer
+			fs, err := NewCompressedForwardSink(&stream, tt.compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range sent {
+				if err := fs.WriteEntry(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := fs.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(stream.Bytes(), []byte(tt.magic)) {
+				t.Errorf("stream starts with %q, want %q", stream.Bytes()[:4], tt.magic)
+			}
+
+			l, s, errs := newForwardReceiver(t)
+			if err := l.ReceiveForwarded(&stream, "child"); err != nil {
+				t.Fatal(err)
+			}
+			if len(*errs) > 0 {
+				t.Errorf("errors: %v", *errs)
+			}
+			if len(s.entries) != len(sent) {
+				t.Fatalf("received %d entries, want %d", len(s.entries), len(sent))
+			}
+			for i, got := range s.entries {
+				want := sent[i]
+				if got.Severity != want.Severity || !got.Time.Equal(want.Time) || got.File != want.File ||
+					got.Line != want.Line || got.Message != want.Message {
+					t.Errorf("entry %d: got %v %v %s:%d, want %v %v %s:%d", i,
+						got.Severity, got.Time, got.File, got.Line, want.Severity, want.Time, want.File, want.Line)
+				}
+				if len(got.Fields) != len(want.Fields)+1 || got.Fields[0].Key != "source" || got.Fields[0].Value != "child" {
+					t.Errorf("entry %d: fields %v", i, got.Fields)
+					continue
+				}
+				for j, f := range want.Fields {
+					g := got.Fields[j+1]
+					if g.Key != f.Key || g.Value != f.String() || g.Privacy != f.Privacy {
+						t.Errorf("entry %d: field %+v, want %+v", i, g, f)
+					}
+				}
+			}
+		})
+	}
+}
+
+// frames returns the frames of entries with the messages.
+func frames(t *testing.T, msgs ...string) [][]byte {
+	t.Helper()
+	var out [][]byte
+	for _, msg := range msgs {
+		var b bytes.Buffer
+		fs := NewForwardSink(&b)
+		fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: msg})
+		fs.Flush()
+		out = append(out, b.Bytes())
+	}
+	return out
+}
+
+func TestForwardResync(t *testing.T) {
+	f := frames(t, "one", "two", "three")
+	badCRC := append([]byte(nil), f[1]...)
+	badCRC[len(badCRC)-2] ^= 0xff
+	badLen := append([]byte(nil), f[1]...)
+	badLen[5] += 100 // claims more bytes than the stream holds
+	hugeLen := append([]byte(nil), f[1]...)
+	hugeLen[2] = 0xff
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name    string
+		stream  []byte
+		want    []string
+		wantErr bool // bad bytes are reported
+	}{
+		{"clean", join(f[0], f[1], f[2]), []string{"one", "two", "three"}, false},
+		{"garbage between frames", join(f[0], []byte("vgvg junk\x00"), f[2]), []string{"one", "three"}, true},
+		{"garbage before the first frame", join([]byte("noise"), f[0], f[2]), []string{"one", "three"}, true},
+		{"bad checksum", join(f[0], badCRC, f[2]), []string{"one", "three"}, true},
+		{"bad length", join(f[0], badLen, f[2]), []string{"one", "three"}, true},
+		{"oversized length", join(f[0], hugeLen, f[2]), []string{"one", "three"}, true},
+		{"cut short", join(f[0], f[1][:len(f[1])-3]), []string{"one"}, true},
+		{"not json", join(f[0], frameBytes(frameMagic, []byte("{oops")), f[2]), []string{"one", "three"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, s, errs := newForwardReceiver(t)
+			if err := l.ReceiveForwarded(bytes.NewReader(tt.stream), ""); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.messages(); !equalNames(got, tt.want) {
+				t.Errorf("received %q, want %q", got, tt.want)
+			}
+			if (len(*errs) > 0) != tt.wantErr {
+				t.Errorf("errors: %v", *errs)
+			}
+		})
+	}
+}
+
+// frameBytes returns a whole frame with the magic and payload.
+func frameBytes(magic [2]byte, payload []byte) []byte {
+	hdr := frameHeader(magic, payload)
+	return append(hdr[:], payload...)
+}
+
+func TestForwardCorruptBlock(t *testing.T) {
+	var stream bytes.Buffer
+	fs, err := NewCompressedForwardSink(&stream, CompressSnappy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: "one"})
+	fs.Flush()
+	good := len(stream.Bytes())
+	fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: "two"})
+	fs.Flush()
+	fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: "three"})
+	fs.Flush()
+	// Corrupt the second block, checksum and all, so only decoding fails.
+	data := stream.Bytes()
+	second := data[good:]
+	n := int(binary.BigEndian.Uint32(second[2:]))
+	payload := []byte("not snappy at all")
+	copy(second, frameBytes(blockMagic, append(payload, make([]byte, n-len(payload))...)))
+
+	l, s, errs := newForwardReceiver(t)
+	if err := l.ReceiveForwarded(bytes.NewReader(data), ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.messages(), []string{"one", "three"}; !equalNames(got, want) {
+		t.Errorf("received %q, want %q", got, want)
+	}
+	if len(*errs) == 0 {
+		t.Error("corrupt block not reported")
+	}
+}
+
+func TestForwardHandshake(t *testing.T) {
+	tests := []struct {
+		name   string
+		offers []byte
+		want   byte
+	}{
+		{"zstd", []byte{'z'}, 'z'},
+		{"snappy", []byte{'s'}, 's'},
+		{"flate", []byte{'f'}, 'f'},
+		{"receiver's preference", []byte{'f', 's', 'z'}, 'z'},
+		{"unknown codec skipped", []byte{'x', 's'}, 's'},
+		{"nothing in common", []byte{'x'}, noCodec},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			l, s, errs := newForwardReceiver(t)
+			done := make(chan error, 1)
+			go func() {
+				defer server.Close()
+				done <- l.receiveForwarded(server, server, "pipe")
+			}()
+
+			hello := append([]byte(streamMagic+string(helloByte)), byte(len(tt.offers)))
+			if _, err := client.Write(append(hello, tt.offers...)); err != nil {
+				t.Fatal(err)
+			}
+			var answer [1]byte
+			if _, err := client.Read(answer[:]); err != nil {
+				t.Fatal(err)
+			}
+			if answer[0] != tt.want {
+				t.Fatalf("receiver picked %q, want %q", answer[0], tt.want)
+			}
+			fs, err := newCodecForwardSink(client, answer[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: "after handshake"})
+			fs.Flush()
+			client.Close()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			if got := s.messages(); len(got) != 1 || got[0] != "after handshake" {
+				t.Errorf("received %q", got)
+			}
+			if len(*errs) > 0 {
+				t.Errorf("errors: %v", *errs)
+			}
+		})
+	}
+}
+
+func TestDialForward(t *testing.T) {
+	tests := []struct {
+		compression ForwardCompression
+		want        byte // codec id, 0 for none
+	}{
+		{CompressNone, 0},
+		{CompressAuto, 0}, // loopback isn't worth compressing
+		{CompressSnappy, 's'},
+		{CompressZstd, 'z'},
+	}
+	for _, tt := range tests {
+		l, s, errs := newForwardReceiver(t)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		served := make(chan struct{})
+		go func() {
+			l.ServeForwarded(ln)
+			close(served)
+		}()
+		fs, err := DialForward(ln.Addr().String(), tt.compression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got byte
+		if fs.codec != nil {
+			got = fs.codec.id
+		}
+		if got != tt.want {
+			t.Errorf("compression %d: codec %q, want %q", tt.compression, got, tt.want)
+		}
+		fs.WriteEntry(&Entry{Severity: SeverityError, Time: time.Now(), Message: "dialed"})
+		fs.Close()
+		for deadline := time.Now().Add(5 * time.Second); len(s.messages()) == 0 && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		ln.Close()
+		<-served
+		l.Close()
+		if msgs := s.messages(); len(msgs) != 1 || msgs[0] != "dialed" {
+			t.Errorf("compression %d: received %q", tt.compression, msgs)
+		}
+		if len(*errs) > 0 {
+			t.Errorf("compression %d: errors %v", tt.compression, *errs)
+		}
+	}
+}
+
+func TestOfferCodecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  byte
+		want    byte
+		wantErr bool
+	}{
+		{"picked", 's', 's', false},
+		{"declined", noCodec, noCodec, false},
+		{"not offered", 'f', 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			go func() {
+				defer server.Close()
+				var hello [len(streamMagic) + 4]byte
+				io.ReadFull(server, hello[:])
+				server.Write([]byte{tt.answer})
+			}()
+			got, err := offerCodecs(client, []byte{'z', 's'})
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("offerCodecs = %q, %v; want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenForwardStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		want    byte // codec id, 0 for none
+		wantErr bool
+	}{
+		{"uncompressed", "vg\x00\x00", 0, false},
+		{"announced codec", "VGFz", 'z', false},
+		{"unknown codec", "VGFq", 0, true},
+		{"offer on a one-way stream", "VGF?\x01z", 0, true},
+		{"short", "VG", 0, false},
+	}
+	for _, tt := range tests {
+		codec, err := openForwardStream(bufio.NewReader(strings.NewReader(tt.stream)), nil)
+		var got byte
+		if codec != nil {
+			got = codec.id
+		}
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: codec %q, error %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIsLocalIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"172.31.255.1", true},
+		{"172.32.0.1", false},
+		{"192.168.1.1", true},
+		{"169.254.1.1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"8.8.8.8", false},
+		{"2001:db8::1", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isLocalIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestForwardSinkParams(t *testing.T) {
+	tests := []struct {
+		params map[string]string
+		ok     bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"addr": "127.0.0.1:1", "compression": "lz4"}, false},
+	}
+	for _, tt := range tests {
+		if _, err := newForwardSinkFromParams(tt.params); (err == nil) != tt.ok {
+			t.Errorf("newForwardSinkFromParams(%v): %v", tt.params, err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package glogcompat
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// severity is glog's severity numbering, used by flags and the bridge.
+type severity int32
+
+const (
+	infoLog severity = iota
+	warningLog
+	errorLog
+	fatalLog
+)
+
+var severityNames = []string{"INFO", "WARNING", "ERROR", "FATAL"}
+
+func severityByName(name string) (severity, bool) {
+	name = strings.ToUpper(name)
+	for i, n := range severityNames {
+		if n == name {
+			return severity(i), true
+		}
+	}
+	return 0, false
+}
+
+// vglogSeverity maps a glog severity onto vglog.
+func (s severity) vglogSeverity() logger.Severity {
+	switch s {
+	case infoLog:
+		return logger.SeverityInfo
+	case warningLog:
+		return logger.SeverityWarning
+	case errorLog:
+		return logger.SeverityError
+	default:
+		return logger.SeverityFatal
+	}
+}
+
+func (s *severity) get() severity {
+	return severity(atomic.LoadInt32((*int32)(s)))
+}
+
+func (s *severity) String() string {
+	return strconv.Itoa(int(s.get()))
+}
+
+// Set 实现flag.Value, 接受级别名或数字
+func (s *severity) Set(value string) error {
+	v, ok := severityByName(value)
+	if !ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > int(fatalLog) {
+			return errors.New("invalid severity: " + value)
+		}
+		v = severity(n)
+	}
+	atomic.StoreInt32((*int32)(s), int32(v))
+	return nil
+}
+
+// Level 详细日志级别, 同glog.Level
+type Level int32
+
+func (l *Level) get() Level {
+	return Level(atomic.LoadInt32((*int32)(l)))
+}
+
+// Get 实现flag.Getter
+func (l *Level) Get() interface{} {
+	return l.get()
+}
+
+// String 实现flag.Value
+func (l *Level) String() string {
+	return strconv.FormatInt(int64(l.get()), 10)
+}
+
+// Set 实现flag.Value
+func (l *Level) Set(value string) error {
+	v, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32((*int32)(l), int32(v))
+	return nil
+}
+
+// verbosityFlag is -v, applied to std.
+type verbosityFlag struct{}
+
+// Get 实现flag.Getter
+func (verbosityFlag) Get() interface{} {
+	return Level(std.Verbosity())
+}
+
+// String 实现flag.Value
+func (verbosityFlag) String() string {
+	return strconv.Itoa(std.Verbosity())
+}
+
+// Set 实现flag.Value
+func (verbosityFlag) Set(value string) error {
+	v, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return err
+	}
+	std.SetVerbosity(int(v))
+	return nil
+}
+
+// vmoduleFlag is -vmodule, applied to std.
+type vmoduleFlag struct{}
+
+func (vmoduleFlag) String() string {
+	return std.VModule()
+}
+
+// Set 实现flag.Value, 格式为"pattern=N,..."
+func (vmoduleFlag) Set(value string) error {
+	return std.SetVModule(value)
+}
+
+// boolFlag is a bool flag that calls apply when set.
+type boolFlag struct {
+	v     int32
+	apply func(bool)
+}
+
+func (b *boolFlag) get() bool {
+	return atomic.LoadInt32(&b.v) != 0
+}
+
+func (b *boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (b *boolFlag) String() string {
+	if b == nil {
+		return "false"
+	}
+	return strconv.FormatBool(b.get())
+}
+
+// Set 实现flag.Value
+func (b *boolFlag) Set(value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	var n int32
+	if v {
+		n = 1
+	}
+	atomic.StoreInt32(&b.v, n)
+	if b.apply != nil {
+		b.apply(v)
+	}
+	return nil
+}
+
+// dirFlag applies -log_dir to the logger as soon as it is parsed.
+type dirFlag struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (d *dirFlag) String() string {
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dir
+}
+
+// Set 实现flag.Value
+func (d *dirFlag) Set(value string) error {
+	d.mu.Lock()
+	d.dir = value
+	d.mu.Unlock()
+	if value != "" {
+		std.SetLogDir(value)
+	}
+	return nil
+}
+
+// traceLocation holds -log_backtrace_at, which is accepted for command
+// line compatibility only.
+type traceLocation struct {
+	mu  sync.Mutex
+	loc string
+}
+
+func (t *traceLocation) String() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.loc
+}
+
+// Set 实现flag.Value, 格式为"file.go:N"
+func (t *traceLocation) Set(value string) error {
+	if value != "" {
+		i := strings.Index(value, ":")
+		if i < 0 {
+			return errors.New("syntax error: expect file.go:234")
+		}
+		if _, err := strconv.Atoi(value[i+1:]); err != nil {
+			return errors.New("syntax error: expect file.go:234")
+		}
+	}
+	t.mu.Lock()
+	t.loc = value
+	t.mu.Unlock()
+	return nil
+}
+
+var (
+	stderrThreshold = errorLog
+	toStderr        = boolFlag{apply: func(v bool) { std.SetFileOutput(!v) }}
+	alsoToStderr    boolFlag
+	logDir          dirFlag
+	traceAt         traceLocation
+)
+
+// stderrSink copies entries to stderr following -logtostderr,
+// -alsologtostderr and -stderrthreshold.
+type stderrSink struct {
+	out *logger.WriterSink
+}
+
+// WriteEntry 实现logger.Sink
+func (s stderrSink) WriteEntry(e *logger.Entry) error {
+	if !toStderr.get() && !alsoToStderr.get() && e.Severity < stderrThreshold.get().vglogSeverity() {
+		return nil
+	}
+	return s.out.WriteEntry(e)
+}
+
+// Flush 实现logger.Sink
+func (s stderrSink) Flush() error {
+	return s.out.Flush()
+}
+
+func init() {
+	flag.Var(&toStderr, "logtostderr", "log to standard error instead of files")
+	flag.Var(&alsoToStderr, "alsologtostderr", "log to standard error as well as files")
+	flag.Var(verbosityFlag{}, "v", "log level for V logs")
+	flag.Var(&stderrThreshold, "stderrthreshold", "logs at or above this threshold go to stderr")
+	flag.Var(vmoduleFlag{}, "vmodule", "comma-separated list of pattern=N settings for file-filtered logging")
+	flag.Var(&traceAt, "log_backtrace_at", "when logging hits line file:N, emit a stack trace (accepted, not implemented)")
+	flag.Var(&logDir, "log_dir", "If non-empty, write log files in this directory")
+}
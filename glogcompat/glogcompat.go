@@ -0,0 +1,202 @@
+// Package glogcompat 以vglog实现github.com/golang/glog的公开API, 替换import路径即可迁移
+//
+// 日志写入本包自己的Logger(见Logger, 以"glog"登记), 不改变logger.DefaultLogger的设置;
+// FATAL级别写入Fatal日志, 随后以255退出进程
+package glogcompat
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// std is the vglog logger every glog call is routed to. It is the
+// package's own, so that importing glogcompat leaves DefaultLogger alone.
+var std = newStd()
+
+// newStd returns a Logger behaving like glog: Info and up, exit code 255
+// on FATAL and stderr output following the glog flags.
+func newStd() *logger.Logger {
+	l := &logger.Logger{}
+	l.SetSeverityLimit(logger.SeverityInfo)
+	l.SetExitCodePolicy(func(*logger.Entry) int { return 255 })
+	l.AddSink(stderrSink{out: logger.NewWriterSink(os.Stderr, nil)})
+	logger.RegisterLogger("glog", l)
+	return l
+}
+
+// Logger 返回glog调用写入的Logger, 可用于调整文件、格式等vglog设置
+func Logger() *logger.Logger {
+	return std
+}
+
+// Verbose 由V返回, 为true时输出日志
+type Verbose bool
+
+// V 判断level级别的详细日志是否开启
+func V(level Level) Verbose {
+	return Verbose(std.VDepth(1, int(level)).Enabled())
+}
+
+// Info 同glog.Verbose.Info
+func (v Verbose) Info(args ...interface{}) {
+	if v {
+		std.InfoDepth(1, fmt.Sprint(args...))
+	}
+}
+
+// Infoln 同glog.Verbose.Infoln
+func (v Verbose) Infoln(args ...interface{}) {
+	if v {
+		std.InfoDepth(1, fmt.Sprintln(args...))
+	}
+}
+
+// Infof 同glog.Verbose.Infof
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v {
+		std.InfoDepth(1, fmt.Sprintf(format, args...))
+	}
+}
+
+// Info 同glog.Info
+func Info(args ...interface{}) {
+	std.InfoDepth(1, fmt.Sprint(args...))
+}
+
+// InfoDepth 同glog.InfoDepth
+func InfoDepth(depth int, args ...interface{}) {
+	std.InfoDepth(depth+1, fmt.Sprint(args...))
+}
+
+// Infoln 同glog.Infoln
+func Infoln(args ...interface{}) {
+	std.InfoDepth(1, fmt.Sprintln(args...))
+}
+
+// Infof 同glog.Infof
+func Infof(format string, args ...interface{}) {
+	std.InfoDepth(1, fmt.Sprintf(format, args...))
+}
+
+// Warning 同glog.Warning
+func Warning(args ...interface{}) {
+	std.WarningDepth(1, fmt.Sprint(args...))
+}
+
+// WarningDepth 同glog.WarningDepth
+func WarningDepth(depth int, args ...interface{}) {
+	std.WarningDepth(depth+1, fmt.Sprint(args...))
+}
+
+// Warningln 同glog.Warningln
+func Warningln(args ...interface{}) {
+	std.WarningDepth(1, fmt.Sprintln(args...))
+}
+
+// Warningf 同glog.Warningf
+func Warningf(format string, args ...interface{}) {
+	std.WarningDepth(1, fmt.Sprintf(format, args...))
+}
+
+// Error 同glog.Error
+func Error(args ...interface{}) {
+	std.ErrorDepth(1, fmt.Sprint(args...))
+}
+
+// ErrorDepth 同glog.ErrorDepth
+func ErrorDepth(depth int, args ...interface{}) {
+	std.ErrorDepth(depth+1, fmt.Sprint(args...))
+}
+
+// Errorln 同glog.Errorln
+func Errorln(args ...interface{}) {
+	std.ErrorDepth(1, fmt.Sprintln(args...))
+}
+
+// Errorf 同glog.Errorf
+func Errorf(format string, args ...interface{}) {
+	std.ErrorDepth(1, fmt.Sprintf(format, args...))
+}
+
+// Fatal 同glog.Fatal: 记录日志及所有goroutine的栈后以255退出
+func Fatal(args ...interface{}) {
+	fatal(2, fmt.Sprint(args...))
+}
+
+// FatalDepth 同glog.FatalDepth
+func FatalDepth(depth int, args ...interface{}) {
+	fatal(depth+2, fmt.Sprint(args...))
+}
+
+// Fatalln 同glog.Fatalln
+func Fatalln(args ...interface{}) {
+	fatal(2, fmt.Sprintln(args...))
+}
+
+// Fatalf 同glog.Fatalf
+func Fatalf(format string, args ...interface{}) {
+	fatal(2, fmt.Sprintf(format, args...))
+}
+
+// Exit 同glog.Exit: 记录日志后以1退出, 不输出栈
+func Exit(args ...interface{}) {
+	exit(2, fmt.Sprint(args...))
+}
+
+// ExitDepth 同glog.ExitDepth
+func ExitDepth(depth int, args ...interface{}) {
+	exit(depth+2, fmt.Sprint(args...))
+}
+
+// Exitln 同glog.Exitln
+func Exitln(args ...interface{}) {
+	exit(2, fmt.Sprintln(args...))
+}
+
+// Exitf 同glog.Exitf
+func Exitf(format string, args ...interface{}) {
+	exit(2, fmt.Sprintf(format, args...))
+}
+
+// fatal logs msg with every goroutine's stack and exits like glog.
+func fatal(depth int, msg string) {
+	std.FatalDepth(depth, msg+"\n"+string(allStacks()))
+}
+
+// exit logs msg without stacks and exits like glog.Exit.
+func exit(depth int, msg string) {
+	std.ErrorDepth(depth, msg)
+	std.Flush()
+	os.Exit(1)
+}
+
+// allStacks returns the stacks of all goroutines, growing the buffer
+// until they fit.
+func allStacks() []byte {
+	n := 10000
+	for {
+		buf := make([]byte, n)
+		if m := runtime.Stack(buf, true); m < len(buf) {
+			return buf[:m]
+		}
+		n *= 2
+	}
+}
+
+// Flush 同glog.Flush
+func Flush() {
+	std.Flush()
+}
+
+// CopyStandardLogTo 同glog.CopyStandardLogTo: 将标准库log的输出转入name级别
+// ("INFO", "WARNING", "ERROR", "FATAL")
+func CopyStandardLogTo(name string) {
+	s, ok := severityByName(name)
+	if !ok {
+		panic(fmt.Sprintf("log.CopyStandardLogTo(%q): unrecognized severity name", name))
+	}
+	std.CopyStandardLogTo(s.vglogSeverity())
+}
@@ -1,3 +1,8 @@
 module github.com/panlibin/vglog
 
 go 1.14
+
+require (
+	github.com/klauspost/compress v1.11.13
+	go.uber.org/goleak v1.1.12
+)
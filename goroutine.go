@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// goid returns the id of the calling goroutine, parsed from the header of
+// its stack trace ("goroutine 18 [running]:").
+func goid() uint64 {
+	var b [64]byte
+	n := runtime.Stack(b[:], false)
+	s := string(b[len("goroutine "):n])
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' {
+			s = s[:i]
+			break
+		}
+	}
+	id, _ := strconv.ParseUint(s, 10, 64)
+	return id
+}
+
+// goroutineFields holds the fields bound to goroutines with BindFields.
+// bound counts the entries so log calls skip the lookup, and the goid cost,
+// while nothing is bound.
+var goroutineFields = struct {
+	sync.RWMutex
+	m     map[uint64][]Field
+	bound int32
+}{m: make(map[uint64][]Field)}
+
+// BindFields 将字段绑定到当前goroutine, 该goroutine的所有日志调用都会附加这些字段; 返回的函数解除绑定
+func BindFields(fields ...Field) (unbind func()) {
+	id := goid()
+	goroutineFields.Lock()
+	old, had := goroutineFields.m[id]
+	merged := make([]Field, 0, len(old)+len(fields))
+	merged = append(merged, old...)
+	goroutineFields.m[id] = append(merged, fields...)
+	if !had {
+		atomic.AddInt32(&goroutineFields.bound, 1)
+	}
+	goroutineFields.Unlock()
+	return func() {
+		goroutineFields.Lock()
+		if had {
+			goroutineFields.m[id] = old
+		} else if _, ok := goroutineFields.m[id]; ok {
+			delete(goroutineFields.m, id)
+			atomic.AddInt32(&goroutineFields.bound, -1)
+		}
+		goroutineFields.Unlock()
+	}
+}
+
+// Go 在新的goroutine中执行fn, 执行期间其日志调用附加fields
+func Go(fn func(), fields ...Field) {
+	go func() {
+		defer BindFields(fields...)()
+		fn()
+	}()
+}
+
+// boundFields returns the fields bound to the calling goroutine.
+func boundFields() []Field {
+	if atomic.LoadInt32(&goroutineFields.bound) == 0 {
+		return nil
+	}
+	id := goid()
+	goroutineFields.RLock()
+	fields := goroutineFields.m[id]
+	goroutineFields.RUnlock()
+	return fields
+}
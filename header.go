@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TimeFormatEpochMillis 供SetTimeFormat使用, 时间写作Unix毫秒数
+const TimeFormatEpochMillis = "epoch_ms"
+
+// defaultTimeLayout and yearTimeLayout are the layouts of the hand-rolled
+// default timestamp, without and with the year.
+const (
+	defaultTimeLayout = "01-02 15:04:05.000000"
+	yearTimeLayout    = "2006-01-02 15:04:05.000000"
+)
+
+// SetTimeFormat 设置日志行头的时间格式: time.Format的layout(如time.RFC3339Nano)或TimeFormatEpochMillis,
+// ""为默认的mm-dd hh:mm:ss.uuuuuu; 格式写在每个日志文件的文件头中, 供reader解析;
+// 修改后已打开的日志文件在下一条日志写入时轮转, 使文件头与日志一致, SetUTC、SetHeaderYear等行头设置同样如此
+func (l *Logger) SetTimeFormat(layout string) {
+	if old := l.getTimeFormat(); old != layout {
+		l.timeFormat.Store(layout)
+		atomic.AddInt32(&l.headerGen, 1)
+	}
+}
+
+// setHeaderOption stores an on/off header option. A change is counted in
+// l.headerGen, so files rotate to a header describing the new format.
+func (l *Logger) setHeaderOption(opt *int32, enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	if atomic.SwapInt32(opt, v) != v {
+		atomic.AddInt32(&l.headerGen, 1)
+	}
+}
+
+// SetUTC 设置日志行头与文件头中的时间是否使用UTC而非本地时间, 便于关联不同时区主机的日志
+func (l *Logger) SetUTC(enabled bool) {
+	l.setHeaderOption(&l.utc, enabled)
+}
+
+// headerTime returns t in the zone of entry and file headers.
+func (l *Logger) headerTime(t time.Time) time.Time {
+	if atomic.LoadInt32(&l.utc) != 0 {
+		return t.UTC()
+	}
+	return t
+}
+
+// SetHeaderYear 设置默认时间格式是否带四位年份(yyyy-mm-dd hh:mm:ss.uuuuuu), 避免长期保存的日志跨年时日期含糊;
+// SetTimeFormat指定了格式时不起作用
+func (l *Logger) SetHeaderYear(enabled bool) {
+	l.setHeaderOption(&l.headerYear, enabled)
+}
+
+// timeLayout returns the layout of entry header timestamps.
+func (l *Logger) timeLayout() string {
+	if layout := l.getTimeFormat(); layout != "" {
+		return layout
+	}
+	if atomic.LoadInt32(&l.headerYear) != 0 {
+		return yearTimeLayout
+	}
+	return defaultTimeLayout
+}
+
+// SetHeaderHost 设置日志行头是否在级别之后带短主机名(第一个'.'之前的部分), 便于汇集多台主机的日志
+func (l *Logger) SetHeaderHost(enabled bool) {
+	l.setHeaderOption(&l.headerHost, enabled)
+}
+
+// SetHeaderPid 设置日志行头是否在级别(及主机名)之后带进程号
+func (l *Logger) SetHeaderPid(enabled bool) {
+	l.setHeaderOption(&l.headerPid, enabled)
+}
+
+// SetHeaderGoroutine 设置日志行头是否带写日志的goroutine号(如g18), 便于区分交错的并发日志; 每条日志需额外读取一次调用栈
+func (l *Logger) SetHeaderGoroutine(enabled bool) {
+	l.setHeaderOption(&l.headerGoid, enabled)
+}
+
+// writeOrigin writes the enabled host and pid header words, and the
+// goroutine id gid if it was recorded.
+func (l *Logger) writeOrigin(buf *buffer, gid uint64) {
+	if atomic.LoadInt32(&l.headerHost) != 0 {
+		buf.WriteString(host)
+		buf.WriteByte(' ')
+	}
+	if atomic.LoadInt32(&l.headerPid) != 0 {
+		n := buf.someDigits(0, pid)
+		buf.tmp[n] = ' '
+		buf.Write(buf.tmp[:n+1])
+	}
+	if gid != 0 {
+		buf.WriteByte('g')
+		buf.WriteString(strconv.FormatUint(gid, 10))
+		buf.WriteByte(' ')
+	}
+}
+
+func (l *Logger) getTimeFormat() string {
+	layout, _ := l.timeFormat.Load().(string)
+	return layout
+}
+
+// formatTime formats t with layout, which isn't the default.
+func formatTime(t time.Time, layout string) string {
+	if layout == TimeFormatEpochMillis {
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return t.Format(layout)
+}
+
+// lineFormat describes the entry header for the "Log line format" line of
+// the file header.
+func (l *Logger) lineFormat() string {
+	layout := l.getTimeFormat()
+	if layout == "" {
+		layout = "mm-dd hh:mm:ss.uuuuuu"
+		if atomic.LoadInt32(&l.headerYear) != 0 {
+			layout = "yyyy-" + layout
+		}
+	}
+	format := "[" + layout + " L "
+	if atomic.LoadInt32(&l.headerHost) != 0 {
+		format += "host "
+	}
+	if atomic.LoadInt32(&l.headerPid) != 0 {
+		format += "pid "
+	}
+	if atomic.LoadInt32(&l.headerGoid) != 0 {
+		format += "g<goroutine> "
+	}
+	format += "file:line] msg"
+	if atomic.LoadInt32(&l.utc) != 0 {
+		format += " (UTC)"
+	}
+	return format
+}
+
+// SetHeaderHost 默认logger快捷调用
+func SetHeaderHost(enabled bool) {
+	DefaultLogger.SetHeaderHost(enabled)
+}
+
+// SetHeaderPid 默认logger快捷调用
+func SetHeaderPid(enabled bool) {
+	DefaultLogger.SetHeaderPid(enabled)
+}
+
+// SetHeaderGoroutine 默认logger快捷调用
+func SetHeaderGoroutine(enabled bool) {
+	DefaultLogger.SetHeaderGoroutine(enabled)
+}
+
+// SetHeaderYear 默认logger快捷调用
+func SetHeaderYear(enabled bool) {
+	DefaultLogger.SetHeaderYear(enabled)
+}
+
+// SetUTC 默认logger快捷调用
+func SetUTC(enabled bool) {
+	DefaultLogger.SetUTC(enabled)
+}
+
+// SetTimeFormat 默认logger快捷调用
+func SetTimeFormat(layout string) {
+	DefaultLogger.SetTimeFormat(layout)
+}
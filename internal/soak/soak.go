@@ -0,0 +1,253 @@
+// Package soak 压力测试: 多goroutine持续写日志并频繁轮转, 结束后读回日志文件,
+// 校验没有丢失、损坏或交错的日志条目
+package soak
+
+import (
+	"bufio"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// logName names the files a run writes, so they can be found again.
+const logName = "soak"
+
+// Config 压测参数
+type Config struct {
+	Dir        string // 日志目录, 为空时使用临时目录并在结束后删除
+	Goroutines int    // 并发写日志的goroutine数
+	Entries    int    // 每个goroutine写的日志条数
+	EntrySize  int    // 每条日志的负载字节数
+	MaxSize    uint64 // 日志文件轮转大小, 越小轮转越频繁
+	Async      int    // 异步队列长度, 0表示同步写
+	Severities bool   // 轮流以Info/Warning/Error写日志, 校验每条日志出现在其级别及以下的每个文件中
+}
+
+// Report 压测结果
+type Report struct {
+	Written    int           // 应读回的日志条数, 写入多个文件的日志按文件计
+	Read       int           // 读回的有效日志条数
+	Lost       int           // 写出但未读回的条数
+	Duplicated int           // 重复读回的条数
+	Corrupted  int           // 无法解析或校验失败的行数, 包括交错的行
+	Files      int           // 日志文件数
+	Elapsed    time.Duration // 写日志耗时
+}
+
+// OK 判断压测是否通过
+func (r Report) OK() bool {
+	return r.Lost == 0 && r.Duplicated == 0 && r.Corrupted == 0 && r.Read == r.Written
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("written=%d read=%d lost=%d duplicated=%d corrupted=%d files=%d elapsed=%v",
+		r.Written, r.Read, r.Lost, r.Duplicated, r.Corrupted, r.Files, r.Elapsed)
+}
+
+// Run 按cfg执行一次压测
+func Run(cfg Config) (Report, error) {
+	var rep Report
+	if cfg.Goroutines <= 0 || cfg.Entries <= 0 {
+		return rep, fmt.Errorf("soak: Goroutines and Entries must be positive")
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		tmp, err := ioutil.TempDir("", "vglog-soak")
+		if err != nil {
+			return rep, err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	var l logger.Logger
+	var (
+		errMu  sync.Mutex
+		logErr error
+	)
+	l.SetErrorHandler(func(err error) {
+		errMu.Lock()
+		if logErr == nil {
+			logErr = err
+		}
+		errMu.Unlock()
+	})
+	l.SetLogDir(dir)
+	l.SetLogName(logName)
+	l.SetSeverityLimit(logger.SeverityInfo)
+	if cfg.MaxSize > 0 {
+		l.SetMaxSize(cfg.MaxSize)
+	}
+	if cfg.Async > 0 {
+		l.SetAsync(cfg.Async)
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for g := 0; g < cfg.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for seq := 0; seq < cfg.Entries; seq++ {
+				msg := message(g, seq, cfg.EntrySize)
+				switch entrySeverity(cfg, g, seq) {
+				case logger.SeverityInfo:
+					l.Info(msg)
+				case logger.SeverityWarning:
+					l.Warning(msg)
+				default:
+					l.Error(msg)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	err := l.Close()
+	rep.Elapsed = time.Since(start)
+	if err != nil {
+		return rep, err
+	}
+	if logErr != nil {
+		return rep, logErr
+	}
+	return rep, verify(dir, cfg, &rep)
+}
+
+// entrySeverity returns the severity entry seq of goroutine g is logged at.
+func entrySeverity(cfg Config, g, seq int) logger.Severity {
+	if !cfg.Severities {
+		return logger.SeverityInfo
+	}
+	return logger.SeverityInfo + logger.Severity((g+seq)%3)
+}
+
+// fileTags are the files a run writes; entries go to every file up to
+// their severity.
+var fileTags = []struct {
+	tag string
+	sev logger.Severity
+}{
+	{"INFO", logger.SeverityInfo},
+	{"WARNING", logger.SeverityWarning},
+	{"ERROR", logger.SeverityError},
+}
+
+// message builds the entry for seq of goroutine g, carrying a checksum of
+// everything else so corruption and interleaving are detected on read.
+func message(g, seq, size int) string {
+	body := fmt.Sprintf("g=%d seq=%d data=%s", g, seq, payload(g, seq, size))
+	return fmt.Sprintf("soak crc=%08x %s", crc32.ChecksumIEEE([]byte(body)), body)
+}
+
+func payload(g, seq, size int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = letters[(g*31+seq*7+i)%len(letters)]
+	}
+	return string(b)
+}
+
+// verify reads back every file of the run and fills in rep.
+func verify(dir string, cfg Config, rep *Report) error {
+	for _, ft := range fileTags {
+		paths, err := filepath.Glob(filepath.Join(dir, logName+"."+ft.tag+".*.log"))
+		if err != nil {
+			return err
+		}
+		rep.Files += len(paths)
+		seen := make([][]bool, cfg.Goroutines)
+		for g := range seen {
+			seen[g] = make([]bool, cfg.Entries)
+		}
+		for _, path := range paths {
+			if err := verifyFile(path, cfg, seen, rep); err != nil {
+				return err
+			}
+		}
+		for g := range seen {
+			for seq, ok := range seen[g] {
+				if entrySeverity(cfg, g, seq) < ft.sev {
+					if ok {
+						rep.Corrupted++ // in a file above its severity
+					}
+					continue
+				}
+				rep.Written++
+				if !ok {
+					rep.Lost++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func verifyFile(path string, cfg Config, seen [][]bool, rep *Report) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), cfg.EntrySize+64*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if isMetadata(line) {
+			continue
+		}
+		g, seq, ok := parseLine(line, cfg)
+		switch {
+		case !ok:
+			rep.Corrupted++
+		case seen[g][seq]:
+			rep.Duplicated++
+		default:
+			seen[g][seq] = true
+			rep.Read++
+		}
+	}
+	return sc.Err()
+}
+
+// isMetadata reports whether line is a file header or rotation marker.
+func isMetadata(line string) bool {
+	return strings.HasPrefix(line, "Log file created at:") ||
+		strings.HasPrefix(line, "Binary:") ||
+		strings.HasPrefix(line, "Log line format:") ||
+		strings.Contains(line, "] log file rotated ")
+}
+
+// parseLine checks one entry line and returns which entry it is.
+func parseLine(line string, cfg Config) (g, seq int, ok bool) {
+	i := strings.Index(line, "] soak crc=")
+	if i < 0 {
+		return 0, 0, false
+	}
+	line = line[i+len("] soak crc="):]
+	if len(line) < 9 || line[8] != ' ' {
+		return 0, 0, false
+	}
+	var sum uint32
+	if _, err := fmt.Sscanf(line[:8], "%08x", &sum); err != nil {
+		return 0, 0, false
+	}
+	body := line[9:]
+	if crc32.ChecksumIEEE([]byte(body)) != sum {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(body, "g=%d seq=%d", &g, &seq); err != nil {
+		return 0, 0, false
+	}
+	if g < 0 || g >= cfg.Goroutines || seq < 0 || seq >= cfg.Entries {
+		return 0, 0, false
+	}
+	return g, seq, true
+}
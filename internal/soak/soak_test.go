@@ -0,0 +1,29 @@
+package soak
+
+import "testing"
+
+// TestEntriesStayWhole checks that entries are never interleaved or torn
+// in any severity file while tiny files rotate in the middle of
+// concurrent writes.
+func TestEntriesStayWhole(t *testing.T) {
+	for _, async := range []int{0, 256} {
+		cfg := Config{
+			Goroutines: 16,
+			Entries:    500,
+			EntrySize:  300,
+			MaxSize:    8 << 10,
+			Async:      async,
+			Severities: true,
+		}
+		rep, err := Run(cfg)
+		if err != nil {
+			t.Fatalf("async=%d: %v", async, err)
+		}
+		if !rep.OK() {
+			t.Errorf("async=%d: %v", async, rep)
+		}
+		if rep.Files < 3 {
+			t.Errorf("async=%d: only %d files, rotation wasn't exercised", async, rep.Files)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package zmsg compresses the long messages vglog writes and expands
+// them again, for the logger itself and for package reader.
+package zmsg
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Prefix starts the marker of a compressed message, "vglog+<codec>:"
+// followed by the compressed message, base64 encoded. The codec name lets
+// the codec change while older files stay readable.
+const Prefix = "vglog+"
+
+// Codec is the name of the codec Compress uses.
+const Codec = "zstd"
+
+var (
+	encOnce sync.Once
+	enc     *zstd.Encoder
+)
+
+// Compress returns the marker holding msg compressed.
+func Compress(msg string) string {
+	encOnce.Do(func() {
+		enc, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	})
+	z := enc.EncodeAll([]byte(msg), nil)
+	return Prefix + Codec + ":" + base64.StdEncoding.EncodeToString(z)
+}
+
+// Expand replaces the compressed messages in text by the original ones.
+// Markers that fail to decode are left as they are, and the first error
+// is returned.
+func Expand(text string) (string, error) {
+	var x expander
+	defer x.close()
+	var firstErr error
+	var out strings.Builder
+	for {
+		i := strings.Index(text, Prefix)
+		if i < 0 {
+			out.WriteString(text)
+			return out.String(), firstErr
+		}
+		out.WriteString(text[:i])
+		start := i + len(Prefix)
+		colon := strings.IndexByte(text[start:], ':')
+		if colon < 0 || !isCodecName(text[start:start+colon]) {
+			out.WriteString(Prefix) // not a marker
+			text = text[start:]
+			continue
+		}
+		codec := text[start : start+colon]
+		start += colon + 1
+		end := start
+		for end < len(text) && isBase64(text[end]) {
+			end++
+		}
+		msg, err := x.expand(codec, text[start:end])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			msg = text[i:end]
+		}
+		out.WriteString(msg)
+		text = text[end:]
+	}
+}
+
+func isCodecName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('a' <= c && c <= 'z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isBase64(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '+' || c == '/' || c == '='
+}
+
+// expander decodes markers, holding a zstd decoder once one is needed.
+type expander struct {
+	dec *zstd.Decoder
+}
+
+func (x *expander) expand(codec, s string) (string, error) {
+	z, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	var msg []byte
+	switch codec {
+	case "zstd":
+		if x.dec == nil {
+			// A zstd decoder runs goroutines until it is closed.
+			if x.dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+				return "", err
+			}
+		}
+		msg, err = x.dec.DecodeAll(z, nil)
+	case "deflate":
+		msg, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(z)))
+	default:
+		return "", fmt.Errorf("unknown message codec %q", codec)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(msg), nil
+}
+
+func (x *expander) close() {
+	if x.dec != nil {
+		x.dec.Close()
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets is the number of histogram buckets; bucket i counts
+// samples below 1µs<<i, the last one everything slower.
+const latencyBuckets = 22
+
+// defaultLatencySampling measures one entry in 64 unless configured.
+const defaultLatencySampling = 64
+
+// latencyStats is a sampled histogram of per-entry format+write latency.
+type latencyStats struct {
+	every   int64 // 0: default, <0: off
+	seq     uint64
+	samples uint64
+	sum     int64
+	buckets [latencyBuckets]uint64
+}
+
+// LatencyHistogram 日志编码与写出耗时的抽样直方图, Counts[i]为耗时小于Bounds[i]的样本数
+// (不含前一个区间), 最后一个区间没有上界
+type LatencyHistogram struct {
+	Bounds  []time.Duration
+	Counts  []uint64
+	Samples uint64
+	Sum     time.Duration
+}
+
+// SetLatencySampling 设置每n条日志测量一次编码与写出耗时, 默认64, n<=0表示关闭
+func (l *Logger) SetLatencySampling(n int) {
+	if n <= 0 {
+		n = -1
+	}
+	atomic.StoreInt64(&l.latency.every, int64(n))
+}
+
+// sample reports whether this entry's latency should be measured.
+func (ls *latencyStats) sample() bool {
+	every := atomic.LoadInt64(&ls.every)
+	if every < 0 {
+		return false
+	}
+	if every == 0 {
+		every = defaultLatencySampling
+	}
+	return atomic.AddUint64(&ls.seq, 1)%uint64(every) == 0
+}
+
+func (ls *latencyStats) record(d time.Duration) {
+	i := 0
+	for i < latencyBuckets-1 && d >= time.Microsecond<<uint(i) {
+		i++
+	}
+	atomic.AddUint64(&ls.buckets[i], 1)
+	atomic.AddUint64(&ls.samples, 1)
+	atomic.AddInt64(&ls.sum, int64(d))
+}
+
+func (ls *latencyStats) histogram() LatencyHistogram {
+	h := LatencyHistogram{
+		Bounds:  make([]time.Duration, latencyBuckets-1),
+		Counts:  make([]uint64, latencyBuckets),
+		Samples: atomic.LoadUint64(&ls.samples),
+		Sum:     time.Duration(atomic.LoadInt64(&ls.sum)),
+	}
+	for i := range h.Bounds {
+		h.Bounds[i] = time.Microsecond << uint(i)
+	}
+	for i := range h.Counts {
+		h.Counts[i] = atomic.LoadUint64(&ls.buckets[i])
+	}
+	return h
+}
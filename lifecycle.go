@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// daemons tracks the background goroutines of a Logger so Close can stop
+// them and wait until they are gone.
+type daemons struct {
+	mu      sync.Mutex
+	stop    chan struct{}
+	closed  bool
+	wg      sync.WaitGroup
+	running int32
+}
+
+// spawn runs fn in a tracked goroutine; fn must return once stop is closed.
+// It reports false after Close.
+func (d *daemons) spawn(fn func(stop <-chan struct{})) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	if d.stop == nil {
+		d.stop = make(chan struct{})
+	}
+	stop := d.stop
+	d.wg.Add(1)
+	atomic.AddInt32(&d.running, 1)
+	go func() {
+		defer d.wg.Done()
+		defer atomic.AddInt32(&d.running, -1)
+		fn(stop)
+	}()
+	return true
+}
+
+// shutdown stops all goroutines and waits for them to return.
+func (d *daemons) shutdown() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		if d.stop != nil {
+			close(d.stop)
+		}
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// Close 写出所有缓冲的日志, 停止所有后台goroutine并关闭日志文件; Close之后的日志被丢弃
+func (l *Logger) Close() error {
+	_, err := l.Drain(context.Background())
+	l.daemons.shutdown()
+	l.endRun()
+	l.closeClasses()
+	l.mu.Lock()
+	l.resetFiles()
+	l.mu.Unlock()
+	return err
+}
+
+// Running 返回是否仍有后台goroutine在运行(含内容类别文件组、轮转回调与ServeForwarded的连接), 用于验证Close后已完全退出
+func (l *Logger) Running() bool {
+	if atomic.LoadInt32(&l.daemons.running) != 0 {
+		return true
+	}
+	for _, c := range l.classes() {
+		if c.Running() {
+			return true
+		}
+	}
+	return false
+}
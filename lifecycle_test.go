@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestCloseStopsGoroutines(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &Logger{}
+	l.SetLogDir(dir)
+	l.SetStderrOutput(false)
+	l.SetErrorHandler(func(err error) { t.Error(err) })
+	l.SetMaxSize(4096)
+	var hooks, hooksDone int32
+	l.SetRotateHook(func(Severity, string) {
+		atomic.AddInt32(&hooks, 1)
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&hooksDone, 1)
+	})
+	l.SetRotationNotify(50, func(Severity, string) {})
+	l.SetClassRetention("pii", time.Hour)
+	l.SetAsync(64)
+	if _, err := l.StartRun(); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan struct{})
+	go func() {
+		l.ServeForwarded(ln)
+		close(served)
+	}()
+	fs, err := DialForward(ln.Addr().String(), CompressNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.Repeat("x", 100)
+	for i := 0; i < 200; i++ {
+		l.Warning(line)
+		l.Errorw("classified", Class("pii"))
+		fs.WriteEntry(&Entry{Severity: SeverityInfo, Time: time.Now(), Message: line})
+	}
+	fs.Flush()
+
+	// The forwarded connection stays open: Close must end its receiver.
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if l.Running() {
+		t.Error("Running() = true after Close")
+	}
+	if n, done := atomic.LoadInt32(&hooks), atomic.LoadInt32(&hooksDone); n == 0 || done != n {
+		t.Errorf("%d of %d rotate hooks done after Close", done, n)
+	}
+	fs.Close()
+	ln.Close()
+	<-served
+}
@@ -0,0 +1,41 @@
+package logger
+
+import "strings"
+
+// defaultLinkFormat names the symlink to the current file of each severity.
+const defaultLinkFormat = "{name}.{tag}"
+
+// SetLinkFormat 设置指向当前日志文件的符号链接名, {name}替换为日志文件名, {tag}替换为日志等级名,
+// 默认为"{name}.{tag}"; format为空时不创建符号链接
+func (l *Logger) SetLinkFormat(format string) {
+	l.mu.Lock()
+	l.linkFormat = format
+	l.linkSet = true
+	l.mu.Unlock()
+}
+
+// linkName returns the symlink name for tag, or "" if links are disabled.
+// l.mu is held.
+func (l *Logger) linkName(tag string) string {
+	format := defaultLinkFormat
+	if l.linkSet {
+		format = l.linkFormat
+	}
+	if format == "" {
+		return ""
+	}
+	return strings.NewReplacer("{name}", l.getLogName(), "{tag}", tag).Replace(format)
+}
+
+// CurrentFiles 返回各日志等级当前写入的文件路径
+func (l *Logger) CurrentFiles() map[Severity]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files := make(map[Severity]string)
+	for _, s := range severityTab().byRank {
+		if sb, ok := l.file[s.index()].(*syncBuffer); ok && sb.path != "" {
+			files[s] = sb.path
+		}
+	}
+	return files
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCurrentFilesKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &Logger{}
+	l.SetLogDir(dir)
+	l.SetLogName("app")
+	l.SetStderrOutput(false)
+	l.SetSeverityLimit(SeverityTrace)
+	l.Error("opens every file from Error down to Trace")
+	defer l.Close()
+
+	files := l.CurrentFiles()
+	for _, s := range []Severity{SeverityTrace, SeverityDebug, SeverityInfo, SeverityWarning, SeverityError} {
+		path, ok := files[s]
+		if !ok {
+			t.Errorf("no file for %v", s)
+			continue
+		}
+		if base := filepath.Base(path); !strings.HasPrefix(base, "app."+s.String()+".") {
+			t.Errorf("file for %v is %s", s, base)
+		}
+	}
+	if path, ok := files[SeverityFatal]; ok {
+		t.Errorf("file for FATAL reported before anything was logged at FATAL: %s", path)
+	}
+}
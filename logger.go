@@ -18,24 +18,17 @@ type Severity int32
 /*
  */
 const (
-	SeverityDebug Severity = iota
+	SeverityTrace Severity = iota - 1
+	SeverityDebug
 	SeverityInfo
 	SeverityWarning
 	SeverityError
+	SeverityFatal
 	severityCount
 )
 
 const flushInterval = 30 * time.Second
 
-var severityChar = "DIWE"
-
-var severityName = []string{
-	SeverityDebug:   "DEBUG",
-	SeverityInfo:    "INFO",
-	SeverityWarning: "WARNING",
-	SeverityError:   "ERROR",
-}
-
 func (s *Severity) get() Severity {
 	return Severity(atomic.LoadInt32((*int32)(s)))
 }
@@ -44,125 +37,285 @@ func (s *Severity) set(val Severity) {
 	atomic.StoreInt32((*int32)(s), int32(val))
 }
 
+func (s *Severity) cas(old, new Severity) bool {
+	return atomic.CompareAndSwapInt32((*int32)(s), int32(old), int32(new))
+}
+
 type flushSyncWriter interface {
 	Flush() error
 	Sync() error
 	io.Writer
+	writeBuffer(buf *buffer) error
 }
 
 // Logger 记录器
 type Logger struct {
-	mu            sync.Mutex
-	file          [severityCount]flushSyncWriter
-	maxSize       uint64
-	logDir        string
-	logName       string
-	severityLimit Severity
-}
-
-func init() {
-	go DefaultLogger.flushDaemon()
-}
-
-func (l *Logger) formatHeader(s Severity, file string, line int) *buffer {
-	now := time.Now()
+	mu                sync.Mutex
+	file              [maxSeverities]flushSyncWriter
+	maxSize           uint64
+	bufferSizes       [maxSeverities]int // flush threshold by severity index, plus one; 0 is the default
+	verbosity         int32
+	vmodule           moduleSpec
+	pkgLevels         atomic.Value // *packageLevels
+	crash             atomic.Value // *crashRing, nil without crash reports
+	development       int32
+	compressThreshold int32
+	firstError        firstError
+	run               atomic.Value // *runState, set by StartRun
+	logDir            string
+	logName           string
+	severityLimit     Severity
+	claimKey          string       // dir+name claimed in openFiles, "" if none
+	consoleOnly       bool         // log dir is unusable, write to stderr only
+	errorHandler      atomic.Value // func(error)
+	maxAge            time.Duration
+	minFreeSpace      uint64
+	diskLow           int32
+	diskOnce          sync.Once
+	writeDeadline     int64 // time.Duration
+	writeStart        int64 // unix nanoseconds of the pending file operation
+	writeStalled      int32
+	deadlineOnce      sync.Once
+	draining          int32
+	dropped           uint64 // entries refused after Drain
+	stderrTee         bool
+	stderr            stderrTee
+	writtenAt         int32
+	asyncMu           sync.RWMutex
+	async             *asyncQueue
+	promotion         promotion
+	counters          counters
+	debugSampledOnly  int32
+	maxOpenFiles      int
+	sinks             []*addedSink
+	sinkFloor         int32
+	cohort            atomic.Value
+	debugTargets      atomic.Value // map[string]func(*Entry) bool
+	classLoggers      atomic.Value // map[string]*Logger
+	lastEntryTime     time.Time
+	clockSteps        uint64
+	lastClockStep     int64  // time.Duration
+	hookOwner         uint64 // goroutine running a sink or the error handler
+	reentered         uint64
+	exitFunc          atomic.Value // func(code int)
+	exitPolicy        atomic.Value // ExitCodePolicy
+	classifier        atomic.Value // ErrorClassifier
+	written           [maxSeverities]uint64
+	latency           latencyStats
+	atomicFinalize    bool
+	rotateInterval    time.Duration
+	idleRotate        time.Duration
+	banner            bool
+	noCaller          int32
+	rotateHook        func(s Severity, path string)
+	noFiles           bool
+	noStderr          bool
+	timeFormat        atomic.Value // string, "" for the default
+	utc               int32
+	headerYear        int32
+	headerHost        int32
+	headerPid         int32
+	headerGoid        int32
+	headerGen         int32 // counts changes to the entry header format
+	stderrLevel       Severity
+	stderrLevelSet    bool
+	consoleSplit      *ConsoleSplit
+	continuation      int32
+	encoder           atomic.Value // encoderHolder
+	linkFormat        string
+	linkSet           bool
+	notifyPercent     int
+	notifyFunc        func(s Severity, path string)
+	stderrEncoder     Encoder
+	ctxAnnotate       int32
+	ctxNear           int64 // time.Duration
+	flushOnce         sync.Once
+	noBackgroundFlush int32
+	daemons           daemons
+}
+
+func (l *Logger) formatHeader(s Severity, now time.Time, file string, line int, gid uint64) *buffer {
 	if line < 0 {
 		line = 0 // not a real line number, but acceptable to someDigits
 	}
-	if s >= severityCount {
+	if !s.valid() {
 		s = SeverityInfo // for safety.
 	}
 	buf := _bufferPool.getBuffer()
+	now = l.headerTime(now)
+	if layout := l.getTimeFormat(); layout != "" {
+		buf.WriteByte('[')
+		buf.WriteString(formatTime(now, layout))
+		buf.tmp[0] = ' '
+		buf.tmp[1] = s.char()
+		buf.tmp[2] = ' '
+		buf.Write(buf.tmp[:3])
+		l.writeOrigin(buf, gid)
+		buf.writeCaller(file, line)
+		return buf
+	}
 
 	// Avoid Fprintf, for speed. The format is so simple that we can do it quickly by hand.
 	// It's worth about 3X. Fprintf is hard.
-	_, month, day := now.Date()
+	year, month, day := now.Date()
 	hour, minute, second := now.Clock()
-	// [mm-dd hh:mm:ss.uuuuuu L file:line]
+	// [mm-dd hh:mm:ss.uuuuuu L file:line], or [yyyy-mm-dd ...] with the year
 	buf.tmp[0] = '['
-	buf.twoDigits(1, int(month))
-	buf.tmp[3] = '-'
-	buf.twoDigits(4, day)
-	buf.tmp[6] = ' '
-	buf.twoDigits(7, hour)
-	buf.tmp[9] = ':'
-	buf.twoDigits(10, minute)
-	buf.tmp[12] = ':'
-	buf.twoDigits(13, second)
-	buf.tmp[15] = '.'
-	buf.nDigits(6, 16, now.Nanosecond()/1000, '0')
-	buf.tmp[22] = ' '
-	buf.tmp[23] = severityChar[s]
-	buf.tmp[24] = ' '
-	buf.Write(buf.tmp[:25])
+	o := 0
+	if atomic.LoadInt32(&l.headerYear) != 0 {
+		buf.nDigits(4, 1, year, '0')
+		buf.tmp[5] = '-'
+		o = 5
+	}
+	buf.twoDigits(o+1, int(month))
+	buf.tmp[o+3] = '-'
+	buf.twoDigits(o+4, day)
+	buf.tmp[o+6] = ' '
+	buf.twoDigits(o+7, hour)
+	buf.tmp[o+9] = ':'
+	buf.twoDigits(o+10, minute)
+	buf.tmp[o+12] = ':'
+	buf.twoDigits(o+13, second)
+	buf.tmp[o+15] = '.'
+	buf.nDigits(6, o+16, now.Nanosecond()/1000, '0')
+	buf.tmp[o+22] = ' '
+	buf.tmp[o+23] = s.char()
+	buf.tmp[o+24] = ' '
+	buf.Write(buf.tmp[:o+25])
+	l.writeOrigin(buf, gid)
+	buf.writeCaller(file, line)
+	return buf
+}
+
+// writeCaller ends the header with "file:line] ".
+func (buf *buffer) writeCaller(file string, line int) {
 	buf.WriteString(file)
 	buf.tmp[0] = ':'
 	n := buf.someDigits(1, line)
 	buf.tmp[n+1] = ']'
 	buf.tmp[n+2] = ' '
 	buf.Write(buf.tmp[:n+3])
-	return buf
 }
 
-func (l *Logger) header(s Severity, depth int) *buffer {
-	_, file, line, ok := runtime.Caller(3 + depth)
+// caller returns the pc, the base name of the file and the line of the
+// function depth frames above its caller.
+func caller(depth int) (uintptr, string, int) {
+	pc, file, line, ok := runtime.Caller(depth + 1)
 	if !ok {
-		file = "???"
-		line = 1
-	} else {
-		slash := strings.LastIndex(file, "/")
-		if slash >= 0 {
-			file = file[slash+1:]
-		}
+		return 0, "???", 1
 	}
-	return l.formatHeader(s, file, line)
+	slash := strings.LastIndex(file, "/")
+	if slash >= 0 {
+		file = file[slash+1:]
+	}
+	return pc, file, line
 }
 
-// createFiles creates all the log files for Severity from sev down to infoLog.
+// createFiles creates all the log files for Severity from sev down to the
+// severity limit that don't exist yet.
 // l.mu is held.
 func (l *Logger) createFiles(sev Severity) error {
+	if err := l.claimFiles(); err != nil {
+		return err
+	}
 	now := time.Now()
-	// Files are created in decreasing Severity order, so as soon as we find one
-	// has already been created, we can stop.
-	for s := sev; s >= l.severityLimit && l.file[s] == nil; s-- {
+	for _, s := range severityTab().span(l.severityLimit.get(), sev) {
+		if l.file[s.index()] != nil {
+			continue
+		}
 		sb := &syncBuffer{
 			logger: l,
 			sev:    s,
 		}
-		if err := sb.rotateFile(now); err != nil {
+		if err := sb.rotateFile(now, ""); err != nil {
 			return err
 		}
-		l.file[s] = sb
+		l.file[s.index()] = sb
 	}
 	return nil
 }
 
-// output writes the data to the log files and releases the buffer.
-func (l *Logger) output(s Severity, buf *buffer) {
-	l.mu.Lock()
-	data := buf.Bytes()
-	if l.file[s] == nil {
-		if err := l.createFiles(s); err != nil {
-			return
-		}
+// output writes e to the sinks and its encoded form buf to the log files,
+// then releases the buffer. The same buffer is shared by reference among all
+// the files it goes to.
+func (l *Logger) output(e *Entry, buf *buffer) {
+	if l.stalled() {
+		l.writeStalledEntry(buf)
+		return
 	}
-
+	if l.reentrant() {
+		l.writeReentrant(buf)
+		return
+	}
+	s := e.Severity
+	l.mu.Lock()
 	slimit := l.severityLimit.get()
-	for i := s; i >= slimit; i-- {
-		l.file[i].Write(data)
+	l.checkClock(e, slimit)
+	l.writeSinks(e)
+	mirror := l.mirrors(s, slimit)
+	fs := s
+	if e.targeted && !fs.atLeast(slimit) {
+		fs = slimit
 	}
-	if slimit == SeverityDebug {
-		os.Stderr.Write(data)
+	if !l.noFiles && !l.writeFiles(fs, slimit, buf) {
+		mirror = true // don't lose the entry; fall back to stderr
+	}
+	tee := false
+	if mirror {
+		tee = l.mirrorStderr(e, buf)
 	}
 
 	l.mu.Unlock()
-	_bufferPool.putBuffer(buf)
-	if s >= SeverityError {
+	_bufferPool.release(buf)
+	l.startFlushDaemon()
+	if tee {
+		l.stderr.flush()
+	}
+	if s.atLeast(SeverityError) {
 		l.Flush()
 	}
 }
 
+// writeFiles writes buf to the files for Severity from s down to slimit,
+// creating them as needed. It reports false if the files can't be used.
+// l.mu is held.
+func (l *Logger) writeFiles(s, slimit Severity, buf *buffer) bool {
+	if !l.openFiles(s, slimit) {
+		return false
+	}
+	for _, i := range severityTab().span(slimit, s) {
+		l.file[i.index()].writeBuffer(buf)
+	}
+	return true
+}
+
+// openFiles makes sure the files for Severity from s down to slimit exist.
+// It reports false if the files can't be used.
+// l.mu is held.
+func (l *Logger) openFiles(s, slimit Severity) bool {
+	if l.consoleOnly {
+		return false
+	}
+	for _, i := range severityTab().span(slimit, s) {
+		if l.file[i.index()] != nil {
+			continue
+		}
+		if err := l.createFiles(s); err != nil {
+			l.reportError(err)
+			if err != ErrDuplicateLogger {
+				l.consoleOnly = true
+			}
+			return false
+		}
+		break
+	}
+	return true
+}
+
 func convDirAbs(dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
 	workDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
 		return dir
@@ -189,20 +342,26 @@ func (l *Logger) SetLogDir(dir string) {
 	l.flushAll()
 	l.resetFiles()
 	l.logDir = dir
+	l.consoleOnly = false
+	if err := probeDir(dir); err != nil {
+		l.consoleOnly = true
+		l.reportError(err)
+	}
 }
 
 func (l *Logger) getLogName() string {
 	if l.logName == "" {
-		l.logName = filepath.Base(os.Args[0])
+		l.logName = sanitizeName(filepath.Base(os.Args[0]))
 	}
 	return l.logName
 }
 
-// SetLogName 设置日志文件名
+// SetLogName 设置日志文件名, 路径分隔符、空白及Windows不允许的字符会被替换为'_'; 为空时恢复默认的程序名
 func (l *Logger) SetLogName(name string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	name = sanitizeName(name)
 	if name == l.logName {
 		return
 	}
@@ -211,18 +370,116 @@ func (l *Logger) SetLogName(name string) {
 	l.logName = name
 }
 
+// SetFileOutput 设置是否写日志文件, 关闭时日志只写入Sink和stderr
+func (l *Logger) SetFileOutput(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if enabled == !l.noFiles {
+		return
+	}
+	l.flushAll()
+	l.resetFiles()
+	l.noFiles = !enabled
+}
+
+// SetAtomicFinalize 设置日志文件是否先写入隐藏的临时文件(.<文件名>.tmp), 轮转或Close时
+// 再原子地重命名为最终文件名, 保证监视日志目录的采集程序不会读到未写完的文件
+func (l *Logger) SetAtomicFinalize(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if enabled == l.atomicFinalize {
+		return
+	}
+	l.flushAll()
+	l.resetFiles()
+	l.atomicFinalize = enabled
+}
+
+// SetErrorHandler 设置内部错误回调, 为nil时错误输出到stderr
+func (l *Logger) SetErrorHandler(fn func(error)) {
+	l.errorHandler.Store(fn)
+}
+
+// reportError hands an internal error to the error handler.
+func (l *Logger) reportError(err error) {
+	if fn, _ := l.errorHandler.Load().(func(error)); fn != nil {
+		marked := l.enterHook()
+		fn(err)
+		l.leaveHook(marked)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+}
+
 // SetSeverityLimit 设置日志打印级别
 func (l *Logger) SetSeverityLimit(s Severity) {
 	l.severityLimit.set(s)
+	l.syncClassLimits()
+}
+
+// startFlushDaemon starts the flushDaemon on first use rather than at
+// package init, so merely importing the package spawns no goroutine.
+func (l *Logger) startFlushDaemon() {
+	if atomic.LoadInt32(&l.noBackgroundFlush) != 0 {
+		return
+	}
+	l.flushOnce.Do(func() { l.daemons.spawn(l.flushDaemon) })
 }
 
 // flushDaemon periodically flushes the log file buffers.
-func (l *Logger) flushDaemon() {
-	for range time.NewTicker(flushInterval).C {
+func (l *Logger) flushDaemon(stop <-chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if atomic.LoadInt32(&l.noBackgroundFlush) != 0 {
+			return
+		}
 		l.Flush()
 	}
 }
 
+// DisableBackgroundFlush 关闭后台定时刷新, 适用于短时运行的命令行程序和测试; 需自行调用Flush
+func (l *Logger) DisableBackgroundFlush() {
+	atomic.StoreInt32(&l.noBackgroundFlush, 1)
+}
+
+// DisableBackgroundFlush 默认logger快捷调用
+func DisableBackgroundFlush() {
+	DefaultLogger.DisableBackgroundFlush()
+}
+
+// SetBufferSize 设置等级s日志文件的缓冲大小, 缓冲满时写入文件; 0表示每条日志直接写入, 负数恢复默认值
+// (Warning及以上16KB, 其余256KB), 内存受限的设备可调小
+func (l *Logger) SetBufferSize(s Severity, size int) {
+	if !s.valid() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if size < 0 {
+		l.bufferSizes[s.index()] = 0
+		return
+	}
+	l.bufferSizes[s.index()] = size + 1
+}
+
+// bufferSize returns the flush threshold of the files of severity s.
+// l.mu is held.
+func (l *Logger) bufferSize(s Severity) int {
+	if n := l.bufferSizes[s.index()]; n > 0 {
+		return n - 1
+	}
+	if s.atLeast(SeverityWarning) {
+		return smallBufferSize
+	}
+	return bufferSize
+}
+
 func (l *Logger) getMaxSize() uint64 {
 	if l.maxSize == 0 {
 		l.maxSize = 1024 * 1024 * 4
@@ -236,50 +493,81 @@ func (l *Logger) SetMaxSize(s uint64) {
 }
 
 func (l *Logger) resetFiles() {
-	for idx := range l.file {
+	for idx, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok {
+			sb.finish()
+		}
 		l.file[idx] = nil
 	}
+	l.releaseFiles()
 }
 
 // Flush 将缓冲写入文件
 func (l *Logger) Flush() {
+	if l.stalled() || l.reentrant() {
+		return
+	}
 	l.mu.Lock()
 	l.flushAll()
 	l.mu.Unlock()
+	for _, c := range l.classes() {
+		c.Flush()
+	}
 }
 
 // flushAll flushes all the logs and attempts to "sync" their data to disk.
 // l.mu is held.
 func (l *Logger) flushAll() {
 	// Flush from fatal down, in case there's trouble flushing.
-	for s := SeverityError; s >= SeverityDebug; s-- {
-		file := l.file[s]
+	t := severityTab().byRank
+	for i := len(t) - 1; i >= 0; i-- {
+		file := l.file[t[i].index()]
 		if file != nil {
 			file.Flush() // ignore error
 			file.Sync()  // ignore error
 		}
 	}
+	for _, as := range l.sinks {
+		as.sink.Flush() // ignore error
+	}
+}
+
+// Enabled 返回s级别的日志当前是否可能被写出, 为false时可跳过构造代价高的参数:
+// if l.Enabled(SeverityDebug) { l.Debug(dump()) }; 开启了定向调试或包级别时较低级别也返回true
+func (l *Logger) Enabled(s Severity) bool {
+	return l.enabled(s)
+}
+
+// Enabled 默认logger快捷调用
+func Enabled(s Severity) bool {
+	return DefaultLogger.enabled(s)
+}
+
+// enabled reports whether entries of severity s are currently written.
+func (l *Logger) enabled(s Severity) bool {
+	if !s.atLeast(l.severityLimit.get()) && !l.sinkWants(s) && !l.targeting() && !l.packageWants(s) {
+		return false
+	}
+	return s.atLeast(SeverityWarning) || !l.lowDisk()
 }
 
 func (l *Logger) println(s Severity, args ...interface{}) {
-	if s < l.severityLimit.get() {
+	if !l.enabled(s) {
 		return
 	}
-	buf := l.header(s, 0)
-	fmt.Fprintln(buf, args...)
-	l.output(s, buf)
+	l.log(l.newEntry(s, 0, fmt.Sprintln(args...)))
 }
 
 func (l *Logger) printf(s Severity, format string, args ...interface{}) {
-	if s < l.severityLimit.get() {
+	if !l.enabled(s) {
 		return
 	}
-	buf := l.header(s, 0)
-	fmt.Fprintf(buf, format, args...)
-	if buf.Bytes()[buf.Len()-1] != '\n' {
-		buf.WriteByte('\n')
-	}
-	l.output(s, buf)
+	l.log(l.newEntry(s, 0, fmt.Sprintf(format, args...)))
+}
+
+// Trace 写Trace日志, 日志等级默认为Debug, 需SetSeverityLimit(SeverityTrace)开启
+func (l *Logger) Trace(args ...interface{}) {
+	l.println(SeverityTrace, args...)
 }
 
 // Debug 写Debug日志
@@ -302,6 +590,11 @@ func (l *Logger) Error(args ...interface{}) {
 	l.println(SeverityError, args...)
 }
 
+// Tracef 写格式化Trace日志
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.printf(SeverityTrace, format, args...)
+}
+
 // Debugf 写格式化Debug日志
 func (l *Logger) Debugf(format string, args ...interface{}) {
 	l.printf(SeverityDebug, format, args...)
@@ -325,6 +618,11 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 // DefaultLogger 默认日志记录器
 var DefaultLogger Logger
 
+// Trace 默认logger快捷调用
+func Trace(args ...interface{}) {
+	DefaultLogger.println(SeverityTrace, args...)
+}
+
 // Debug 默认logger快捷调用
 func Debug(args ...interface{}) {
 	DefaultLogger.println(SeverityDebug, args...)
@@ -345,6 +643,11 @@ func Error(args ...interface{}) {
 	DefaultLogger.println(SeverityError, args...)
 }
 
+// Tracef 默认logger快捷调用
+func Tracef(format string, args ...interface{}) {
+	DefaultLogger.printf(SeverityTrace, format, args...)
+}
+
 // Debugf 默认logger快捷调用
 func Debugf(format string, args ...interface{}) {
 	DefaultLogger.printf(SeverityDebug, format, args...)
@@ -0,0 +1,114 @@
+// Package logtest 为使用vglog的应用提供测试辅助: 捕获日志输出并与golden文件比对
+package logtest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// UpdateEnv 设置该环境变量(非空)时, AssertGolden用实际输出覆盖golden文件
+const UpdateEnv = "VGLOG_UPDATE_GOLDEN"
+
+// Recorder 捕获Logger输出的Sink
+type Recorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	enc logger.Encoder
+}
+
+// NewRecorder 创建Recorder, enc为nil时使用logger.TextEncoder
+func NewRecorder(enc logger.Encoder) *Recorder {
+	if enc == nil {
+		enc = logger.TextEncoder{}
+	}
+	return &Recorder{enc: enc}
+}
+
+// New 创建不写文件的Logger及捕获其全部输出的Recorder
+func New() (*logger.Logger, *Recorder) {
+	l := new(logger.Logger)
+	l.SetFileOutput(false)
+	r := NewRecorder(nil)
+	l.AddSink(r)
+	return l, r
+}
+
+// WriteEntry 实现logger.Sink
+func (r *Recorder) WriteEntry(e *logger.Entry) error {
+	r.mu.Lock()
+	r.enc.EncodeEntry(&r.buf, e)
+	r.mu.Unlock()
+	return nil
+}
+
+// Flush 实现logger.Sink
+func (r *Recorder) Flush() error {
+	return nil
+}
+
+// Bytes 返回已捕获的输出
+func (r *Recorder) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+// Reset 清空已捕获的输出
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.buf.Reset()
+	r.mu.Unlock()
+}
+
+// normalizers replace the parts of an entry that change from run to run.
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// text header: [mm-dd hh:mm:ss.uuuuuu L file:line]
+	{regexp.MustCompile(`\[\d\d-\d\d \d\d:\d\d:\d\d\.\d{6} (\S+) ([^:\]]+):\d+\]`), "[00-00 00:00:00.000000 $1 $2:0]"},
+	// console: hh:mm:ss
+	{regexp.MustCompile(`(?m)^(\x1b\[\d+m)?\d\d:\d\d:\d\d `), "${1}00:00:00 "},
+	// written_at field
+	{regexp.MustCompile(`written_at=\d\d-\d\d \d\d:\d\d:\d\d\.\d{6}`), "written_at=00-00 00:00:00.000000"},
+	// json time and caller
+	{regexp.MustCompile(`"time":"[^"]*"`), `"time":"0001-01-01T00:00:00Z"`},
+	{regexp.MustCompile(`"caller":"([^":]+):\d+"`), `"caller":"$1:0"`},
+}
+
+// Normalize 将日志中的时间和行号替换为固定值, 使输出可与golden文件比对
+func Normalize(data []byte) []byte {
+	for _, n := range normalizers {
+		data = n.re.ReplaceAll(data, []byte(n.repl))
+	}
+	return data
+}
+
+// AssertGolden 将规范化后的输出与golden文件path比对, 不一致时报告测试失败; 设置UpdateEnv环境变量时改为更新golden文件
+func (r *Recorder) AssertGolden(tb testing.TB, path string) {
+	tb.Helper()
+	got := Normalize(r.Bytes())
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("logtest: %v", err)
+		}
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			tb.Fatalf("logtest: %v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("logtest: %v (set %s=1 to create it)", err, UpdateEnv)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("logtest: output differs from %s\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// MemoryPolicy 超出内存预算时的处理方式
+type MemoryPolicy struct {
+	Keep Severity // 不低于Keep的日志照常写入, 其余丢弃
+	Sync bool     // 异步模式下改为同步写入, 不再增加队列占用
+}
+
+// memBudget accounts the memory held by all Loggers: entries queued for
+// async writers, entry buffers pending in files and the buffer pool.
+// Buffers shared by several files are counted once per file, erring on
+// the safe side.
+var memBudget struct {
+	limit  int64 // 0 means unlimited
+	used   int64
+	shed   uint64
+	policy atomic.Value // MemoryPolicy
+}
+
+// SetMemoryLimit 设置所有Logger共用的内存预算(字节), 覆盖异步队列、待写缓冲与缓冲池; 超出时按policy丢弃或改为同步写,
+// 并停止缓存空闲缓冲; limit<=0表示不限制. 当前用量与丢弃条数见Metrics
+func SetMemoryLimit(limit int64, policy MemoryPolicy) {
+	if limit < 0 {
+		limit = 0
+	}
+	memBudget.policy.Store(policy)
+	atomic.StoreInt64(&memBudget.limit, limit)
+}
+
+func memAcquire(n int) {
+	atomic.AddInt64(&memBudget.used, int64(n))
+}
+
+func memRelease(n int) {
+	atomic.AddInt64(&memBudget.used, -int64(n))
+}
+
+// memOver reports whether the budget is exceeded.
+func memOver() bool {
+	limit := atomic.LoadInt64(&memBudget.limit)
+	return limit > 0 && atomic.LoadInt64(&memBudget.used) >= limit
+}
+
+func memPolicy() MemoryPolicy {
+	p, _ := memBudget.policy.Load().(MemoryPolicy)
+	return p
+}
+
+// shed reports whether e must be dropped to stay within the budget,
+// counting it if so.
+func (e *Entry) shed() bool {
+	if !memOver() || e.Severity.atLeast(memPolicy().Keep) {
+		return false
+	}
+	atomic.AddUint64(&memBudget.shed, 1)
+	return true
+}
+
+// entryCost estimates the memory an entry holds while queued.
+func entryCost(e *Entry) int {
+	n := int(unsafe.Sizeof(*e)) + len(e.Message) + len(e.File)
+	for _, f := range e.Fields {
+		n += int(unsafe.Sizeof(f)) + len(f.Key)
+		if s, ok := f.Value.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics 日志统计
+type Metrics struct {
+	Counters   map[string]uint64   // RegisterCounter注册的计数器
+	Severities map[Severity]uint64 // 各级别写出的日志条数
+	Latency    LatencyHistogram    // 抽样的编码与写出耗时
+	MemoryUsed int64               // 所有Logger计入内存预算的字节数
+	MemoryShed uint64              // 所有Logger因超出内存预算丢弃的日志条数
+}
+
+// logCounter counts the entries whose message matches re.
+type logCounter struct {
+	name  string
+	re    *regexp.Regexp
+	value uint64
+}
+
+// counters holds the registered log-based counters. The slice is replaced,
+// never modified, so matching only needs a read lock.
+type counters struct {
+	mu   sync.RWMutex
+	list []*logCounter
+}
+
+// RegisterCounter 注册计数器, 消息匹配正则表达式expr的日志使计数器name加一; 同名计数器会被替换
+func (l *Logger) RegisterCounter(name, expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	c := &l.counters
+	c.mu.Lock()
+	list := make([]*logCounter, 0, len(c.list)+1)
+	for _, lc := range c.list {
+		if lc.name != name {
+			list = append(list, lc)
+		}
+	}
+	c.list = append(list, &logCounter{name: name, re: re})
+	c.mu.Unlock()
+	return nil
+}
+
+// count increments the counters matching e.
+func (c *counters) count(e *Entry) {
+	c.mu.RLock()
+	list := c.list
+	c.mu.RUnlock()
+	for _, lc := range list {
+		if lc.re.MatchString(e.Message) {
+			atomic.AddUint64(&lc.value, 1)
+		}
+	}
+}
+
+// Metrics 返回日志统计
+func (l *Logger) Metrics() Metrics {
+	m := Metrics{
+		Counters:   make(map[string]uint64),
+		Severities: make(map[Severity]uint64),
+		Latency:    l.latency.histogram(),
+		MemoryUsed: atomic.LoadInt64(&memBudget.used),
+		MemoryShed: atomic.LoadUint64(&memBudget.shed),
+	}
+	for _, s := range severityTab().byRank {
+		m.Severities[s] = atomic.LoadUint64(&l.written[s.index()])
+	}
+	l.counters.mu.RLock()
+	for _, lc := range l.counters.list {
+		m.Counters[lc.name] = atomic.LoadUint64(&lc.value)
+	}
+	l.counters.mu.RUnlock()
+	return m
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLen bounds the logName part of file names, leaving room for the
+// tag, timestamp and pid within the usual 255 byte file name limit. Paths
+// longer than MAX_PATH are handled by the os package on Windows.
+const maxNameLen = 128
+
+// windowsReserved are device names Windows refuses as file names, with or
+// without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeName turns a binary or configured name into something every
+// supported filesystem can open. Unicode letters are kept; path separators,
+// whitespace, control and Windows-reserved characters become '_'. An empty
+// name stays empty, meaning the default; a name with nothing usable left
+// becomes "log".
+func sanitizeName(name string) string {
+	if name == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for i, r := range name {
+		if sb.Len()+utf8.RuneLen(r) > maxNameLen {
+			break
+		}
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(name[i:], string(utf8.RuneError)):
+			r = '_' // invalid UTF-8
+		case strings.ContainsRune(`/\<>:"|?*`, r), unicode.IsSpace(r), unicode.IsControl(r):
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	s := strings.Trim(sb.String(), ". ")
+	if s == "" {
+		return "log"
+	}
+	base := strings.ToUpper(s)
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if windowsReserved[base] {
+		s = "_" + s
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"app", "app"},
+		{"my app", "my_app"},
+		{`a/b\c:d`, "a_b_c_d"},
+		{"..", "log"},
+		{".", "log"},
+		{"con", "_con"},
+		{"NUL.txt", "_NUL.txt"},
+		{"服务", "服务"},
+		{"bad\xffname", "bad_name"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeName(tt.in); got != tt.want {
+			t.Errorf("sanitizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetLogNameEmptyRestoresDefault(t *testing.T) {
+	l := &Logger{}
+	defer l.Close()
+	def := l.getLogName()
+	if want := sanitizeName(filepath.Base(os.Args[0])); def != want {
+		t.Fatalf("default name = %q, want %q", def, want)
+	}
+	l.SetLogName("custom")
+	if got := l.getLogName(); got != "custom" {
+		t.Fatalf("after SetLogName(\"custom\"): %q", got)
+	}
+	l.SetLogName("")
+	if got := l.getLogName(); got != def {
+		t.Errorf("after SetLogName(\"\"): %q, want default %q", got, def)
+	}
+}
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// pkgPrefix is the prefix of the function names of this package.
+var pkgPrefix = reflect.TypeOf(Logger{}).PkgPath() + "."
+
+// skipPrefixes holds the []string of function name prefixes left out of
+// stack traces.
+var skipPrefixes atomic.Value
+
+func init() {
+	skipPrefixes.Store([]string{pkgPrefix})
+}
+
+// SetStackSkipPrefixes 设置日志调用栈中省略的函数名前缀, 如"runtime."或封装日志的包路径; 本包的函数总是被省略
+func SetStackSkipPrefixes(prefixes ...string) {
+	list := make([]string, 0, len(prefixes)+1)
+	list = append(list, pkgPrefix)
+	list = append(list, prefixes...)
+	skipPrefixes.Store(list)
+}
+
+// skipFrame reports whether the frame of function fn is left out of stacks.
+func skipFrame(fn string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(fn, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// stack returns the calling goroutine's stack, skipping the frames of this
+// package and the configured prefixes so it starts at the user's call site.
+func stack() string {
+	prefixes := skipPrefixes.Load().([]string)
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(1, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if !skipFrame(f.Function, prefixes) {
+			fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// panicKind classifies a recovered panic value.
+func panicKind(v interface{}) string {
+	switch v.(type) {
+	case runtime.Error:
+		return "runtime.Error"
+	case error:
+		return "error"
+	}
+	return "value"
+}
+
+// FormatPanic 格式化recover得到的panic值, 首行为"panic: <runtime.Error|error|value> (<类型>): <值>", 随后为调用栈
+func FormatPanic(v interface{}) string {
+	return fmt.Sprintf("panic: %s (%T): %v\n%s", panicKind(v), v, v, stack())
+}
+
+func (l *Logger) printPanic(s Severity, v interface{}) {
+	if !l.enabled(s) {
+		return
+	}
+	e := l.newEntry(s, 0, FormatPanic(v))
+	l.log(e)
+	if l.crashRing() != nil {
+		l.settle()
+		l.writeCrashReport(e, stack())
+	}
+}
+
+// LogPanic 以Error等级记录recover得到的panic值及调用栈, 在defer中调用
+func (l *Logger) LogPanic(v interface{}) {
+	l.printPanic(SeverityError, v)
+}
+
+// LogPanic 默认logger快捷调用
+func LogPanic(v interface{}) {
+	DefaultLogger.printPanic(SeverityError, v)
+}
+
+// panicMsg logs msg at Error, waits until it is written and flushed, then
+// panics with msg.
+func (l *Logger) panicMsg(msg string) {
+	if l.enabled(SeverityError) {
+		e := l.newEntry(SeverityError, 0, msg)
+		l.log(e)
+		l.settle()
+		l.Flush()
+		l.writeCrashReport(e, stack())
+	}
+	panic(strings.TrimSuffix(msg, "\n"))
+}
+
+// Panic 写Error日志并刷新后以该消息panic
+func (l *Logger) Panic(args ...interface{}) {
+	l.panicMsg(fmt.Sprintln(args...))
+}
+
+// Panicf 写格式化Error日志并刷新后以该消息panic
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.panicMsg(fmt.Sprintf(format, args...))
+}
+
+// Panic 默认logger快捷调用
+func Panic(args ...interface{}) {
+	DefaultLogger.panicMsg(fmt.Sprintln(args...))
+}
+
+// Panicf 默认logger快捷调用
+func Panicf(format string, args ...interface{}) {
+	DefaultLogger.panicMsg(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// packageLevels maps package import paths to their severity limit. It is
+// replaced, never modified, so lookups need no lock.
+type packageLevels struct {
+	levels map[string]Severity
+	lowest Severity // the lowest of levels, below which nothing is logged
+	pcs    sync.Map // pc -> Severity, or nil without an override
+}
+
+// SetPackageSeverity 设置从包pkg(完整导入路径, 如"github.com/me/svc/db")中调用的日志的等级下限, 可高于或低于Logger的等级;
+// 依赖调用位置, 关闭SetCallerLookup后不生效
+func (l *Logger) SetPackageSeverity(pkg string, s Severity) {
+	l.updatePackageLevels(func(m map[string]Severity) { m[pkg] = s })
+}
+
+// RemovePackageSeverity 移除包pkg的等级设置
+func (l *Logger) RemovePackageSeverity(pkg string) {
+	l.updatePackageLevels(func(m map[string]Severity) { delete(m, pkg) })
+}
+
+func (l *Logger) updatePackageLevels(fn func(map[string]Severity)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := make(map[string]Severity)
+	if old := l.packageLevels(); old != nil {
+		for k, v := range old.levels {
+			m[k] = v
+		}
+	}
+	fn(m)
+	var pl *packageLevels
+	if len(m) > 0 {
+		pl = &packageLevels{levels: m}
+		first := true
+		for _, s := range m {
+			if first || !s.atLeast(pl.lowest) {
+				pl.lowest, first = s, false
+			}
+		}
+	}
+	l.pkgLevels.Store(pl)
+}
+
+func (l *Logger) packageLevels() *packageLevels {
+	pl, _ := l.pkgLevels.Load().(*packageLevels)
+	return pl
+}
+
+// packageWants reports whether some package override lets s through.
+func (l *Logger) packageWants(s Severity) bool {
+	pl := l.packageLevels()
+	return pl != nil && s.atLeast(pl.lowest)
+}
+
+// packageLevel returns the limit set for the package e was logged from.
+func (l *Logger) packageLevel(e *Entry) (Severity, bool) {
+	pl := l.packageLevels()
+	if pl == nil || e.pc == 0 {
+		return 0, false
+	}
+	if v, ok := pl.pcs.Load(e.pc); ok {
+		s, ok := v.(Severity)
+		return s, ok
+	}
+	var v interface{}
+	if fn := runtime.FuncForPC(e.pc); fn != nil {
+		if s, ok := pl.levels[funcPackage(fn.Name())]; ok {
+			v = s
+		}
+	}
+	pl.pcs.Store(e.pc, v)
+	s, ok := v.(Severity)
+	return s, ok
+}
+
+// funcPackage returns the import path of the package of the function
+// named name, such as "github.com/me/svc/db" for
+// "github.com/me/svc/db.(*Conn).Query".
+func funcPackage(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+	return name
+}
+
+// SetPackageSeverity 默认logger快捷调用
+func SetPackageSeverity(pkg string, s Severity) {
+	DefaultLogger.SetPackageSeverity(pkg, s)
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+)
+
+// recordSink keeps the messages of the entries written to it.
+type recordSink struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *recordSink) WriteEntry(e *Entry) error {
+	r.mu.Lock()
+	r.msgs = append(r.msgs, e.Severity.String()+" "+e.Message)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recordSink) Flush() error { return nil }
+
+func (r *recordSink) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msgs := r.msgs
+	r.msgs = nil
+	return msgs
+}
+
+// newRecordedLogger returns a Logger writing only to the returned sink.
+func newRecordedLogger(t *testing.T) (*Logger, *recordSink) {
+	l := &Logger{}
+	t.Cleanup(func() { l.Close() })
+	l.SetFileOutput(false)
+	l.SetStderrOutput(false)
+	r := &recordSink{}
+	l.AddSink(r)
+	return l, r
+}
+
+func TestFuncPackage(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"main.main", "main"},
+		{"net/http.(*Server).Serve", "net/http"},
+		{"github.com/me/svc/db.(*Conn).Query", "github.com/me/svc/db"},
+		{"github.com/me/svc/db.Open.func1", "github.com/me/svc/db"},
+		{"gopkg.in/yaml.v2.Unmarshal", "gopkg.in/yaml"},
+		{"noDot", "noDot"},
+	}
+	for _, tt := range tests {
+		if got := funcPackage(tt.name); got != tt.want {
+			t.Errorf("funcPackage(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPackageSeverity(t *testing.T) {
+	const self = "github.com/panlibin/vglog"
+	tests := []struct {
+		name   string
+		limit  Severity
+		levels map[string]Severity
+		want   []string
+	}{
+		{
+			name:  "no override",
+			limit: SeverityWarning,
+			want:  []string{"WARNING w", "ERROR e"},
+		},
+		{
+			name:   "lower for this package",
+			limit:  SeverityWarning,
+			levels: map[string]Severity{self: SeverityDebug},
+			want:   []string{"DEBUG d", "INFO i", "WARNING w", "ERROR e"},
+		},
+		{
+			name:   "higher for this package",
+			limit:  SeverityDebug,
+			levels: map[string]Severity{self: SeverityError},
+			want:   []string{"ERROR e"},
+		},
+		{
+			name:   "other package only",
+			limit:  SeverityWarning,
+			levels: map[string]Severity{"github.com/me/svc/db": SeverityTrace},
+			want:   []string{"WARNING w", "ERROR e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, r := newRecordedLogger(t)
+			l.SetSeverityLimit(tt.limit)
+			for pkg, s := range tt.levels {
+				l.SetPackageSeverity(pkg, s)
+			}
+			l.Debug("d")
+			l.Info("i")
+			l.Warning("w")
+			l.Error("e")
+			if got := r.take(); !equalNames(got, tt.want) {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovePackageSeverity(t *testing.T) {
+	l, r := newRecordedLogger(t)
+	l.SetSeverityLimit(SeverityWarning)
+	l.SetPackageSeverity("github.com/panlibin/vglog", SeverityInfo)
+	l.SetPackageSeverity("github.com/me/svc/db", SeverityTrace)
+	if pl := l.packageLevels(); pl == nil || pl.lowest != SeverityTrace {
+		t.Fatalf("lowest package level: %+v", pl)
+	}
+	l.RemovePackageSeverity("github.com/me/svc/db")
+	if pl := l.packageLevels(); pl == nil || pl.lowest != SeverityInfo {
+		t.Fatalf("lowest package level after removal: %+v", pl)
+	}
+	l.RemovePackageSeverity("github.com/panlibin/vglog")
+	if l.packageLevels() != nil {
+		t.Fatal("package levels left after removing them all")
+	}
+	l.Info("i")
+	if got := r.take(); len(got) != 0 {
+		t.Errorf("logged %q after removing the package level", got)
+	}
+}
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The preserve list, <dir>/<name>.preserve, maps the base names of log
+// files that retention cleanup must keep to the reasons they are kept.
+
+// preservePath returns the path of l's preserve list.
+// l.mu is held.
+func (l *Logger) preservePath() string {
+	return filepath.Join(l.getLogDir(), l.getLogName()+".preserve")
+}
+
+// readPreserved loads the preserve list at path; a missing list preserves
+// nothing. Lists written before files kept several reasons map a name to
+// a single reason string.
+func readPreserved(path string) (map[string][]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("preserve list %s: %v", path, err)
+	}
+	m := make(map[string][]string, len(raw))
+	for name, v := range raw {
+		var reasons []string
+		if err := json.Unmarshal(v, &reasons); err != nil {
+			var reason string
+			if json.Unmarshal(v, &reason) != nil {
+				return nil, fmt.Errorf("preserve list %s: %v", path, err)
+			}
+			reasons = []string{reason}
+		}
+		m[name] = reasons
+	}
+	return m, nil
+}
+
+// writePreserved replaces the preserve list at path with m, removing the
+// list when m is empty.
+func writePreserved(path string, m map[string][]string) error {
+	if len(m) == 0 {
+		err := removeFile(path)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return renameFile(tmp, path)
+}
+
+// preserveFiles adds names to the preserve list with reason.
+// l.mu is held.
+func (l *Logger) preserveFiles(names []string, reason string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	path := l.preservePath()
+	m, err := readPreserved(path)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = make(map[string][]string, len(names))
+	}
+	for _, name := range names {
+		if !hasString(m[name], reason) {
+			m[name] = append(m[name], reason)
+		}
+	}
+	return writePreserved(path, m)
+}
+
+// runFiles returns the base names of l's log files written by the process
+// with the given pid during a run from start until it was last seen. Pids
+// repeat (every run in a container may be pid 1), so a file also needs
+// the stamp in its name or its modification time within the run; the
+// window extends a heartbeat past seen, as the run went on after it.
+// l.mu is held.
+func (l *Logger) runFiles(pid int, start, seen time.Time) []string {
+	infos, err := ioutil.ReadDir(l.getLogDir())
+	if err != nil {
+		return nil
+	}
+	prefix := l.getLogName() + "."
+	suffix := "." + strconv.Itoa(pid) + ".log"
+	from, until := start.Truncate(time.Second), seen.Add(runHeartbeat)
+	within := func(t time.Time) bool {
+		return !t.Before(from) && !t.After(until)
+	}
+	var names []string
+	for _, fi := range infos {
+		name := fi.Name()
+		if !fi.Mode().IsRegular() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		if !hasSeverityTag(rest) {
+			continue
+		}
+		stamped := false
+		if i := strings.IndexByte(rest, '.'); i >= 0 && len(rest) >= i+16 {
+			if t, err := time.ParseInLocation("20060102-150405", rest[i+1:i+16], time.Local); err == nil {
+				stamped = within(t)
+			}
+		}
+		if stamped || within(fi.ModTime()) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// PreserveCurrent 将当前正在写的日志文件加入保留列表, 使其不被SetMaxAge清理(如故障期间保全证据), 直到以同一reason调用ReleasePreserved
+func (l *Logger) PreserveCurrent(reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var names []string
+	for _, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok {
+			names = append(names, filepath.Base(finalName(sb.path, sb.final)))
+		}
+	}
+	return l.preserveFiles(names, reason)
+}
+
+// ReleasePreserved 撤销以reason对日志文件的保留, 不再有任何保留原因的文件之后按SetMaxAge正常清理
+func (l *Logger) ReleasePreserved(reason string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	path := l.preservePath()
+	m, err := readPreserved(path)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for name, reasons := range m {
+		if !hasString(reasons, reason) {
+			continue
+		}
+		changed = true
+		kept := reasons[:0]
+		for _, r := range reasons {
+			if r != reason {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m, name)
+		} else {
+			m[name] = kept
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writePreserved(path, m)
+}
+
+// Preserved 返回保留列表中的日志文件名及其保留原因
+func (l *Logger) Preserved() (map[string][]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m, err := readPreserved(l.preservePath())
+	if m == nil && err == nil {
+		m = make(map[string][]string)
+	}
+	return m, err
+}
+
+func hasString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PreserveCurrent 默认logger快捷调用
+func PreserveCurrent(reason string) error {
+	return DefaultLogger.PreserveCurrent(reason)
+}
+
+// ReleasePreserved 默认logger快捷调用
+func ReleasePreserved(reason string) error {
+	return DefaultLogger.ReleasePreserved(reason)
+}
@@ -0,0 +1,69 @@
+package logger
+
+// Privacy 字段隐私级别, 数值越大越敏感
+type Privacy uint8
+
+// 隐私级别
+const (
+	PrivacyPublic Privacy = iota
+	PrivacyInternal
+	PrivacySensitive
+)
+
+// redacted replaces field values a sink may not receive.
+const redacted = "[redacted]"
+
+// Internal 创建内部级别的日志字段
+func Internal(key string, value interface{}) Field {
+	return Field{Key: key, Value: value, Privacy: PrivacyInternal}
+}
+
+// Sensitive 创建敏感级别的日志字段
+func Sensitive(key string, value interface{}) Field {
+	return Field{Key: key, Value: value, Privacy: PrivacySensitive}
+}
+
+// PrivacySink 由Sink实现, 声明其可接收的最高隐私级别;
+// 超出级别的字段值在写入前被替换. 未实现的Sink接收完整字段
+type PrivacySink interface {
+	Sink
+	MaxPrivacy() Privacy
+}
+
+// privacySink caps an existing sink's privacy level.
+type privacySink struct {
+	Sink
+	max Privacy
+}
+
+func (ps *privacySink) MaxPrivacy() Privacy {
+	return ps.max
+}
+
+// WithMaxPrivacy 包装sink, 使其只接收不超过max级别的字段
+func WithMaxPrivacy(sink Sink, max Privacy) PrivacySink {
+	return &privacySink{Sink: sink, max: max}
+}
+
+// sanitized returns e with field values above max redacted, or e itself
+// when nothing needs redacting.
+func (e *Entry) sanitized(max Privacy) *Entry {
+	i := 0
+	for ; i < len(e.Fields); i++ {
+		if e.Fields[i].Privacy > max {
+			break
+		}
+	}
+	if i == len(e.Fields) {
+		return e
+	}
+	c := *e
+	c.Fields = make([]Field, len(e.Fields))
+	copy(c.Fields, e.Fields)
+	for ; i < len(c.Fields); i++ {
+		if c.Fields[i].Privacy > max {
+			c.Fields[i].Value = redacted
+		}
+	}
+	return &c
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxPromotionKeys bounds the number of distinct warnings tracked for
+// promotion; expired windows are pruned once it is reached.
+const maxPromotionKeys = 4096
+
+// promotion escalates a Warning to Error once the same message recurs more
+// than limit times within window.
+type promotion struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	counts map[string]*promotionCount
+}
+
+type promotionCount struct {
+	n     int
+	start time.Time
+}
+
+// SetWarningPromotion 设置同一条Warning在window内出现超过n次后提升为Error, n<=0表示关闭
+func (l *Logger) SetWarningPromotion(n int, window time.Duration) {
+	p := &l.promotion
+	p.mu.Lock()
+	p.limit = n
+	p.window = window
+	p.counts = nil
+	p.mu.Unlock()
+}
+
+// promote raises e to Error if its message has repeated too often.
+func (p *promotion) promote(e *Entry) {
+	if e.Severity != SeverityWarning {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.limit <= 0 {
+		return
+	}
+	if p.counts == nil {
+		p.counts = make(map[string]*promotionCount)
+	}
+	c := p.counts[e.Message]
+	if c == nil || e.Time.Sub(c.start) > p.window {
+		if c == nil && len(p.counts) >= maxPromotionKeys {
+			p.prune(e.Time)
+		}
+		c = &promotionCount{start: e.Time}
+		p.counts[e.Message] = c
+	}
+	c.n++
+	if c.n > p.limit {
+		e.Severity = SeverityError
+		e.Message += fmt.Sprintf(" (promoted: repeated %d times in %v)", c.n, p.window)
+	}
+}
+
+// prune drops the counts whose window has passed.
+// p.mu is held.
+func (p *promotion) prune(now time.Time) {
+	for k, c := range p.counts {
+		if now.Sub(c.start) > p.window {
+			delete(p.counts, k)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// purgeResult counts what a purge changed.
+type purgeResult struct {
+	Files   int
+	Entries int
+}
+
+// Purge 改写保留中的日志文件(包括正在写的文件和SetClassRetention的文件组), 删除带有字段field=value的日志条目,
+// 并在日志目录的<日志名>.purge.audit中追加一条审计记录(只记录value的哈希及删除数量)
+func (l *Logger) Purge(field, value string) error {
+	_, err := l.purge(field, value)
+	return err
+}
+
+func (l *Logger) purge(field, value string) (purgeResult, error) {
+	var res purgeResult
+	if field == "" {
+		return res, fmt.Errorf("logger: purge: empty field name")
+	}
+	m := newPurgeMatcher(field, value)
+	// Hold off new async entries and write out the queued ones, so that
+	// none carrying the field lands in a file after it is rewritten.
+	l.asyncMu.Lock()
+	if q := l.async; q != nil {
+		done := make(chan struct{})
+		q.ch <- &Entry{done: done}
+		<-done
+	}
+	l.mu.Lock()
+	l.flushAll()
+	for _, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok {
+			sb.close() // reopened by path, i.e. the rewritten file
+		}
+	}
+	dir := l.getLogDir()
+	name := l.getLogName()
+	err := purgeFiles(dir, name, m, &res)
+	for _, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok && sb.path != "" {
+			if fi, serr := os.Stat(sb.path); serr == nil {
+				sb.nbytes = uint64(fi.Size())
+			}
+		}
+	}
+	if err == nil {
+		err = writePurgeAudit(dir, name, field, value, res)
+	}
+	l.mu.Unlock()
+	l.asyncMu.Unlock()
+	if err != nil {
+		return res, err
+	}
+	for _, c := range l.classes() {
+		cres, err := c.purge(field, value)
+		res.Files += cres.Files
+		res.Entries += cres.Entries
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// purgeFiles rewrites the files of the Logger named name in dir.
+func purgeFiles(dir, name string, m *purgeMatcher, res *purgeResult) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	prefix := name + "."
+	for _, fi := range infos {
+		base := fi.Name()
+		if strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".tmp") {
+			base = strings.TrimSuffix(base[1:], ".tmp") // atomic finalize
+		}
+		if !fi.Mode().IsRegular() || !strings.HasPrefix(base, prefix) || !strings.HasSuffix(base, ".log") ||
+			!hasSeverityTag(base[len(prefix):]) {
+			continue
+		}
+		n, err := purgeFile(filepath.Join(dir, fi.Name()), fi.Mode(), m)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			res.Files++
+			res.Entries += n
+		}
+	}
+	return nil
+}
+
+// purgeFile removes the matching entries of path through a temporary file
+// renamed over it, and returns how many were removed.
+func purgeFile(path string, mode os.FileMode, m *purgeMatcher) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var out, entry bytes.Buffer
+	removed := 0
+	// Entries are matched as a whole, since with continuation lines the
+	// fields end up on the last line.
+	endEntry := func() {
+		if m.match(expandCompressed(bytes.TrimSuffix(entry.Bytes(), []byte{'\n'}))) {
+			removed++
+		} else {
+			out.Write(entry.Bytes())
+		}
+		entry.Reset()
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), len(data)+1)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if !isContinuation(line) {
+			endEntry()
+		}
+		entry.Write(line)
+		entry.WriteByte('\n')
+	}
+	endEntry()
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".purge")
+	if err != nil {
+		return 0, err
+	}
+	_, err = f.Write(out.Bytes())
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = renameFile(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return 0, err
+	}
+	return removed, nil
+}
+
+// isContinuation reports whether line continues a multi-line entry
+// written with SetContinuationPrefix.
+func isContinuation(line []byte) bool {
+	return len(line) >= 3 && strings.IndexByte(severityTab().chars, line[0]) >= 0 && line[1] == '|' && line[2] == ' '
+}
+
+// purgeMatcher finds field=value in text and JSON encoded lines.
+type purgeMatcher struct {
+	text [][]byte
+	json [][]byte
+}
+
+func newPurgeMatcher(field, value string) *purgeMatcher {
+	m := &purgeMatcher{}
+	for _, v := range []string{value, strconv.Quote(value)} {
+		m.text = append(m.text, []byte(" "+field+"="+v))
+	}
+	key, _ := json.Marshal(field)
+	str, _ := json.Marshal(value)
+	m.json = append(m.json, append(append(key, ':'), str...))
+	if _, err := strconv.ParseFloat(value, 64); err == nil || value == "true" || value == "false" {
+		m.json = append(m.json, append(append(key, ':'), value...))
+	}
+	return m
+}
+
+// match reports whether the entry, possibly spanning several lines,
+// carries the field.
+func (m *purgeMatcher) match(line []byte) bool {
+	for _, p := range m.text {
+		if containsDelimited(line, p, " \n") {
+			return true
+		}
+	}
+	for _, p := range m.json {
+		if containsDelimited(line, p, ",}") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsDelimited reports whether p occurs in line followed by the end
+// of the line or one of the bytes in delims.
+func containsDelimited(line, p []byte, delims string) bool {
+	for {
+		i := bytes.Index(line, p)
+		if i < 0 {
+			return false
+		}
+		end := i + len(p)
+		if end == len(line) || strings.IndexByte(delims, line[end]) >= 0 {
+			return true
+		}
+		line = line[i+1:]
+	}
+}
+
+// writePurgeAudit appends a record of the purge to the audit file. Only a
+// hash of value is kept, so the audit trail holds no personal data.
+func writePurgeAudit(dir, name, field, value string, res purgeResult) error {
+	sum := sha256.Sum256([]byte(value))
+	rec, _ := json.Marshal(struct {
+		Time    string `json:"time"`
+		Field   string `json:"field"`
+		Value   string `json:"value_sha256"`
+		Files   int    `json:"files"`
+		Entries int    `json:"entries"`
+	}{time.Now().Format(time.RFC3339), field, hex.EncodeToString(sum[:]), res.Files, res.Entries})
+	f, err := openFile(filepath.Join(dir, name+".purge.audit"), os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(rec, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Purge 默认logger快捷调用
+func Purge(field, value string) error {
+	return DefaultLogger.Purge(field, value)
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPurgeMatcher(t *testing.T) {
+	tests := []struct {
+		field, value string
+		line         string
+		want         bool
+	}{
+		{"user", "42", `[01-02 03:04:05.000000 E a.go:1] msg user=42`, true},
+		{"user", "42", `[01-02 03:04:05.000000 E a.go:1] msg user=42 op=get`, true},
+		{"user", "42", `[01-02 03:04:05.000000 E a.go:1] msg user=421`, false},
+		{"user", "42", `[01-02 03:04:05.000000 E a.go:1] msg xuser=42`, false},
+		{"user", "a b", `[01-02 03:04:05.000000 E a.go:1] msg user="a b"`, true},
+		{"user", "42", `{"msg":"m","user":"42"}`, true},
+		{"user", "42", `{"msg":"m","user":42,"op":"get"}`, true},
+		{"user", "42", `{"msg":"m","user":"421"}`, false},
+		{"user", "bob", `{"msg":"m","user":bob}`, false},
+		{"admin", "true", `{"admin":true}`, true},
+	}
+	for _, tt := range tests {
+		if got := newPurgeMatcher(tt.field, tt.value).match([]byte(tt.line)); got != tt.want {
+			t.Errorf("match(%s=%s, %s) = %v, want %v", tt.field, tt.value, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	tests := []struct {
+		name string
+		enc  Encoder
+	}{
+		{"text", nil},
+		{"json", JSONEncoder{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "vglog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			l := &Logger{}
+			l.SetLogDir(dir)
+			l.SetLogName("app")
+			l.SetStderrOutput(false)
+			l.SetErrorHandler(func(err error) { t.Error(err) })
+			l.SetEncoder(tt.enc)
+			defer l.Close()
+			l.Errorw("first", F("user", "42"))
+			l.Errorw("second", F("user", "421"))
+			l.Errorw("third", F("user", "42"), F("op", "get"))
+			l.Errorw("fourth")
+
+			// Error entries are in the ERROR file and every lower one.
+			files := len(l.CurrentFiles())
+			res, err := l.purge("user", "42")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Files != files || res.Entries != 2*files {
+				t.Errorf("purged %d entries in %d files, want %d in %d", res.Entries, res.Files, 2*files, files)
+			}
+			// The files stay usable after being rewritten.
+			l.Errorw("fifth", F("user", "7"))
+			l.Flush()
+
+			data, err := ioutil.ReadFile(l.CurrentFiles()[SeverityError])
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := string(data)
+			for _, msg := range []string{"first", "third"} {
+				if strings.Contains(text, msg) {
+					t.Errorf("%q left after purge:\n%s", msg, text)
+				}
+			}
+			for _, msg := range []string{"second", "fourth", "fifth"} {
+				if strings.Count(text, msg) != 1 {
+					t.Errorf("%q not kept once:\n%s", msg, text)
+				}
+			}
+
+			audit, err := ioutil.ReadFile(filepath.Join(dir, "app.purge.audit"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rec map[string]interface{}
+			if err := json.Unmarshal(audit, &rec); err != nil {
+				t.Fatalf("audit record %s: %v", audit, err)
+			}
+			if rec["field"] != "user" || rec["entries"] != float64(res.Entries) {
+				t.Errorf("audit record %s", audit)
+			}
+			for k, v := range rec {
+				if v == "42" {
+					t.Errorf("audit records the purged value as %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeEmptyField(t *testing.T) {
+	l := &Logger{}
+	defer l.Close()
+	l.SetFileOutput(false)
+	if err := l.Purge("", "42"); err == nil {
+		t.Error("Purge with an empty field succeeded")
+	}
+}
@@ -0,0 +1,8 @@
+package reader
+
+import "github.com/panlibin/vglog/internal/zmsg"
+
+// Expand 还原text中以logger.CompressedPrefix标记的压缩消息, 其余文本不变; 无法解压的标记原样保留并返回第一个错误
+func Expand(text string) (string, error) {
+	return zmsg.Expand(text)
+}
@@ -0,0 +1,104 @@
+package reader
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ExportOptions 导出日志时的筛选与匿名化规则
+type ExportOptions struct {
+	From, To time.Time        // 时间范围[From, To), 零值表示不限
+	Redact   []*regexp.Regexp // 匹配的文本替换为"[redacted]"
+	Pseudo   []string         // 这些字段的值替换为假名, 相同的值得到相同的假名
+	Secret   []byte           // 生成假名的密钥, 不同密钥的导出之间无法关联
+}
+
+// Export 将paths中时间范围内的日志按规则匿名化后写入dst, 用于与外部分享日志
+func Export(dst io.Writer, paths []string, opts ExportOptions) error {
+	w := bufio.NewWriter(dst)
+	p := newPseudonymizer(opts)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		sc := NewScanner(f)
+		for sc.Scan() {
+			e := sc.Entry()
+			if !opts.From.IsZero() && e.Time.Before(opts.From) || !opts.To.IsZero() && !e.Time.Before(opts.To) {
+				continue
+			}
+			w.WriteString(p.apply(e))
+			w.WriteByte('\n')
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// pseudonymizer rewrites entries following ExportOptions.
+type pseudonymizer struct {
+	opts   ExportOptions
+	fields []*regexp.Regexp
+}
+
+func newPseudonymizer(opts ExportOptions) *pseudonymizer {
+	p := &pseudonymizer{opts: opts}
+	for _, key := range opts.Pseudo {
+		k := regexp.QuoteMeta(key)
+		// key=value, key="quoted value" and "key":json-value.
+		p.fields = append(p.fields, regexp.MustCompile(
+			`( `+k+`=)("(?:[^"\\]|\\.)*"|[^ \n]*)|("`+k+`":)("(?:[^"\\]|\\.)*"|[^,}]*)`))
+	}
+	return p
+}
+
+func (p *pseudonymizer) apply(e Entry) string {
+	raw := e.Raw
+	for _, re := range p.fields {
+		raw = re.ReplaceAllStringFunc(raw, func(m string) string {
+			sub := re.FindStringSubmatch(m)
+			if sub[1] != "" {
+				return sub[1] + p.token(unquoteText(sub[2]))
+			}
+			return sub[3] + strconv.Quote(p.token(unquoteJSON(sub[4])))
+		})
+	}
+	for _, re := range p.opts.Redact {
+		raw = re.ReplaceAllString(raw, "[redacted]")
+	}
+	return raw
+}
+
+// token returns the pseudonym of v.
+func (p *pseudonymizer) token(v string) string {
+	mac := hmac.New(sha256.New, p.opts.Secret)
+	mac.Write([]byte(v))
+	return "anon_" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+func unquoteText(v string) string {
+	if s, err := strconv.Unquote(v); err == nil {
+		return s
+	}
+	return v
+}
+
+func unquoteJSON(v string) string {
+	var s string
+	if json.Unmarshal([]byte(v), &s) == nil {
+		return s
+	}
+	return v
+}
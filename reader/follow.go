@@ -0,0 +1,217 @@
+package reader
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// followPoll is how often Follow checks for new output and rotation.
+const followPoll = 200 * time.Millisecond
+
+// Follow 像tail -F一样持续读取path处的日志, path通常是指向当前日志文件的符号链接(如<name>.INFO),
+// 从当前文件开头读起, 轮转后读完旧文件再转到链接指向的新文件; 没有链接时(SetLinkFormat("")或Windows)
+// 读取同目录下最新的<path>.*.log文件; prefixes同Scanner.SetPrefixes; 调用stop停止读取, 随后channel关闭
+func Follow(path string, prefixes ...string) (<-chan Entry, func()) {
+	f := &follower{
+		path:     path,
+		prefixes: prefixes,
+		out:      make(chan Entry),
+		stop:     make(chan struct{}),
+	}
+	go f.run()
+	var once sync.Once
+	return f.out, func() { once.Do(func() { close(f.stop) }) }
+}
+
+// follower tails the file at path, one file at a time.
+type follower struct {
+	path     string
+	prefixes []string
+	out      chan Entry
+	stop     chan struct{}
+	f        *os.File
+	name     string   // final name of the file read without a link
+	s        *Scanner // parse state of the current file
+	partial  string   // last line of the file, not yet ended
+	raw      string   // entry waiting for its continuation lines
+	pending  bool
+}
+
+func (f *follower) run() {
+	defer close(f.out)
+	defer func() {
+		if f.f != nil {
+			f.f.Close()
+		}
+	}()
+	buf := make([]byte, 64*1024)
+	for {
+		if f.f == nil {
+			f.open()
+		}
+		if f.f != nil {
+			n, err := f.f.Read(buf)
+			if n > 0 {
+				if !f.feed(buf[:n]) {
+					return
+				}
+				continue
+			}
+			if err != nil && err != io.EOF {
+				f.f.Close()
+				f.f = nil
+			} else {
+				// An entry is written in one piece, so at the end of
+				// complete output the pending entry has no more lines.
+				if f.partial == "" && !f.flush() {
+					return
+				}
+				if f.rotated() {
+					if !f.finish() {
+						return
+					}
+					continue
+				}
+			}
+		}
+		select {
+		case <-f.stop:
+			return
+		case <-time.After(followPoll):
+		}
+	}
+}
+
+// open opens the current file, if there is one yet.
+func (f *follower) open() {
+	path := f.path
+	if _, err := os.Stat(path); err != nil {
+		path, f.name = f.nextLog()
+		if path == "" {
+			return
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	f.f = file
+	f.s = newScanner()
+	f.s.SetPrefixes(f.prefixes...)
+	f.partial = ""
+}
+
+// nextLog returns, when there is no link at path, the file to read next:
+// the one after the file last read, or the newest to begin with. Files
+// still written under their temporary name count as well, and name is
+// the file's final name.
+func (f *follower) nextLog() (path, name string) {
+	dir, prefix := filepath.Split(f.path)
+	infos, err := ioutil.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return "", ""
+	}
+	prefix += "."
+	for _, fi := range infos {
+		n := fi.Name()
+		if strings.HasPrefix(n, ".") && strings.HasSuffix(n, ".tmp") {
+			n = strings.TrimSuffix(n[1:], ".tmp")
+		}
+		if !fi.Mode().IsRegular() || !strings.HasPrefix(n, prefix) || !strings.HasSuffix(n, ".log") {
+			continue
+		}
+		// The stamp after the tag sorts chronologically.
+		switch {
+		case f.name == "" && n > name, // the newest
+			f.name != "" && n > f.name && (name == "" || n < name): // the next
+			path, name = filepath.Join(dir, fi.Name()), n
+		}
+	}
+	return path, name
+}
+
+// rotated reports whether the open file is no longer the current one.
+func (f *follower) rotated() bool {
+	cur, err := f.f.Stat()
+	if err != nil {
+		return true
+	}
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		next, _ := f.nextLog()
+		return next != ""
+	}
+	return !os.SameFile(cur, fi)
+}
+
+// finish reads what is left of the rotated file and closes it.
+func (f *follower) finish() bool {
+	data, _ := ioutil.ReadAll(f.f)
+	f.f.Close()
+	f.f = nil
+	if !f.feed(data) {
+		return false
+	}
+	if f.partial != "" {
+		line := f.partial
+		f.partial = ""
+		if !f.line(line) {
+			return false
+		}
+	}
+	return f.flush()
+}
+
+// feed splits data into lines. It returns false once stopped.
+func (f *follower) feed(data []byte) bool {
+	f.partial += string(data)
+	for {
+		i := strings.IndexByte(f.partial, '\n')
+		if i < 0 {
+			return true
+		}
+		line := f.partial[:i]
+		f.partial = f.partial[i+1:]
+		if !f.line(line) {
+			return false
+		}
+	}
+}
+
+// line adds a line to the pending entry or starts a new one, skipping
+// file headers and stray text.
+func (f *follower) line(line string) bool {
+	if f.s.header(line) {
+		return true
+	}
+	if f.s.isEntryStart(line) {
+		if !f.flush() {
+			return false
+		}
+		f.raw, f.pending = line, true
+		return true
+	}
+	if f.pending {
+		f.raw += "\n" + line
+	}
+	return true
+}
+
+// flush sends the pending entry. It returns false once stopped.
+func (f *follower) flush() bool {
+	if !f.pending {
+		return true
+	}
+	f.pending = false
+	select {
+	case f.out <- f.s.parse(f.raw):
+		return true
+	case <-f.stop:
+		return false
+	}
+}
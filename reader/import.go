@@ -0,0 +1,269 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// Format 可导入的外部日志格式
+type Format string
+
+// 支持的导入格式
+const (
+	FormatJSON   Format = "json"   // 每行一个JSON对象
+	FormatLogfmt Format = "logfmt" // 每行一组key=value
+	FormatGlog   Format = "glog"   // glog文本格式, 如"I0102 15:04:05.000000 123 file.go:10] msg"
+)
+
+// OrigTimeKey 导入日志中保存原始时间的字段
+const OrigTimeKey = "orig_time"
+
+// Keys recognized as the time, level, message and caller of JSON and
+// logfmt entries, in order of preference.
+var (
+	timeKeys   = []string{"time", "ts", "timestamp", "@timestamp", "t"}
+	levelKeys  = []string{"level", "lvl", "severity", "@level"}
+	msgKeys    = []string{"msg", "message", "@message"}
+	callerKeys = []string{"caller", "source"}
+)
+
+// Import 按format解析r中的日志, 逐条写入l并返回写入条数; 原始时间保存在OrigTimeKey字段,
+// 其余键值作为字段保留, 无法解析的行作为Info日志原样写入(glog格式下属于上一条日志)
+func Import(l *logger.Logger, r io.Reader, format Format) (int, error) {
+	var parse func(line string) (*logger.Entry, bool)
+	switch format {
+	case FormatJSON:
+		parse = parseJSONLine
+	case FormatLogfmt:
+		parse = parseLogfmtLine
+	case FormatGlog:
+		return importGlog(l, r)
+	default:
+		return 0, fmt.Errorf("reader: unknown import format %q", format)
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	n := 0
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		e, ok := parse(line)
+		if !ok {
+			e = newImported(logger.SeverityInfo, "", line)
+		}
+		l.LogEntry(e)
+		n++
+	}
+	return n, sc.Err()
+}
+
+// newImported returns an entry logged now that keeps orig, the original
+// timestamp, in a field.
+func newImported(s logger.Severity, orig, msg string) *logger.Entry {
+	e := &logger.Entry{Severity: s, Time: time.Now(), File: "???", Line: 1, Message: msg}
+	if orig != "" {
+		e.Fields = append(e.Fields, logger.F(OrigTimeKey, orig))
+	}
+	return e
+}
+
+// fromPairs builds an entry from the key/value pairs of a JSON or logfmt
+// line; the pairs that aren't time, level, message or caller become fields.
+func fromPairs(keys []string, values map[string]string) *logger.Entry {
+	used := make(map[string]bool)
+	pick := func(names []string) string {
+		for _, k := range names {
+			if v, ok := values[k]; ok {
+				used[k] = true
+				return v
+			}
+		}
+		return ""
+	}
+	e := newImported(parseLevel(pick(levelKeys)), normalizeTime(pick(timeKeys)), pick(msgKeys))
+	if c := pick(callerKeys); c != "" {
+		if i := strings.LastIndexByte(c, ':'); i > 0 {
+			if line, err := strconv.Atoi(c[i+1:]); err == nil {
+				e.File, e.Line = c[:i], line
+			}
+		}
+	}
+	for _, k := range keys {
+		if !used[k] {
+			e.Fields = append(e.Fields, logger.F(k, values[k]))
+		}
+	}
+	return e
+}
+
+func parseJSONLine(line string) (*logger.Entry, bool) {
+	var obj map[string]interface{}
+	if json.Unmarshal([]byte(line), &obj) != nil {
+		return nil, false
+	}
+	keys := make([]string, 0, len(obj))
+	values := make(map[string]string, len(obj))
+	for k, v := range obj {
+		keys = append(keys, k)
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case float64:
+			values[k] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			b, _ := json.Marshal(v)
+			values[k] = string(b)
+		}
+	}
+	sort.Strings(keys) // the object's key order is lost
+	return fromPairs(keys, values), true
+}
+
+func parseLogfmtLine(line string) (*logger.Entry, bool) {
+	var keys []string
+	values := make(map[string]string)
+	for i := 0; i < len(line); {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+		eq := strings.IndexByte(line[i:], '=')
+		if eq <= 0 || strings.IndexByte(line[i:i+eq], ' ') >= 0 {
+			return nil, false
+		}
+		key := line[i : i+eq]
+		i += eq + 1
+		var val string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil, false
+			}
+			v, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, false
+			}
+			val, i = v, end+1
+		} else {
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			val, i = line[i:i+end], i+end
+		}
+		if _, dup := values[key]; !dup {
+			keys = append(keys, key)
+		}
+		values[key] = val
+	}
+	if len(keys) == 0 {
+		return nil, false
+	}
+	return fromPairs(keys, values), true
+}
+
+// glogLine matches the header of a glog entry:
+// Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+var glogLine = regexp.MustCompile(`^([IWEF])(\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s+\d+ ([^:\]]+):(\d+)\] ?(.*)$`)
+
+// importGlog imports glog text, where lines not starting with a header
+// continue the previous entry. glog leaves out the year; the current one
+// is assumed.
+func importGlog(l *logger.Logger, r io.Reader) (int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	year := time.Now().Year()
+	n := 0
+	var cur *logger.Entry
+	emit := func() {
+		if cur != nil {
+			l.LogEntry(cur)
+			n++
+			cur = nil
+		}
+	}
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		m := glogLine.FindStringSubmatch(line)
+		if m == nil {
+			if strings.HasPrefix(line, "Log file created at: ") || strings.HasPrefix(line, "Running on machine: ") ||
+				strings.HasPrefix(line, "Binary: ") || strings.HasPrefix(line, "Log line format: ") {
+				continue
+			}
+			if cur != nil {
+				cur.Message += "\n" + line
+			} else if strings.TrimSpace(line) != "" {
+				cur = newImported(logger.SeverityInfo, "", line)
+			}
+			continue
+		}
+		emit()
+		orig := m[2]
+		if t, err := time.ParseInLocation("0102 15:04:05.000000", m[2], time.Local); err == nil {
+			orig = t.AddDate(year-t.Year(), 0, 0).Format(time.RFC3339Nano)
+		}
+		cur = newImported(parseLevel(m[1]), orig, m[5])
+		cur.File = m[3]
+		cur.Line, _ = strconv.Atoi(m[4])
+	}
+	emit()
+	return n, sc.Err()
+}
+
+// parseLevel maps the level names used by common loggers and the
+// registered severities onto a Severity, defaulting to Info.
+func parseLevel(level string) logger.Severity {
+	switch strings.ToLower(level) {
+	case "trace", "t":
+		return logger.SeverityTrace
+	case "debug", "dbg", "d":
+		return logger.SeverityDebug
+	case "warn", "warning", "w":
+		return logger.SeverityWarning
+	case "error", "err", "e":
+		return logger.SeverityError
+	case "fatal", "panic", "crit", "critical", "f":
+		return logger.SeverityFatal
+	}
+	if s, err := logger.ParseSeverity(level); err == nil {
+		return s // a custom severity
+	}
+	return logger.SeverityInfo
+}
+
+// normalizeTime returns t as RFC 3339 if it is in a known layout or a Unix
+// timestamp in seconds or milliseconds, and unchanged otherwise.
+func normalizeTime(t string) string {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006/01/02 15:04:05.999999999"} {
+		if pt, err := time.ParseInLocation(layout, t, time.Local); err == nil {
+			return pt.Format(time.RFC3339Nano)
+		}
+	}
+	if f, err := strconv.ParseFloat(t, 64); err == nil && f > 0 {
+		if f >= 1e11 {
+			f /= 1000
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).Format(time.RFC3339Nano)
+	}
+	return t
+}
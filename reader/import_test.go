@@ -0,0 +1,164 @@
+package reader
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// importSink keeps what Import writes, as "SEVERITY file:line msg k=v...".
+type importSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *importSink) WriteEntry(e *logger.Entry) error {
+	line := e.Severity.String() + " " + e.File + ":" + strconv.Itoa(e.Line) + " " + e.Message
+	for _, f := range e.Fields {
+		line += " " + f.Key + "=" + f.String()
+	}
+	s.mu.Lock()
+	s.lines = append(s.lines, line)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *importSink) Flush() error { return nil }
+
+func TestImport(t *testing.T) {
+	year := time.Now().Year()
+	glogTime := time.Date(year, 1, 2, 15, 4, 5, 123456000, time.Local).Format(time.RFC3339Nano)
+	unixTime := time.Unix(1583298367, 0).Format(time.RFC3339Nano)
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		want   []string
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			input: `{"ts":1583298367,"level":"warn","msg":"hello","caller":"a.go:7","user":"bob","n":3}` + "\n\n" +
+				`{"message":"no level","extra":{"a":1}}` + "\n" +
+				"not json\n",
+			want: []string{
+				"WARNING a.go:7 hello orig_time=" + unixTime + " n=3 user=bob",
+				`INFO ???:1 no level extra={"a":1}`,
+				"INFO ???:1 not json",
+			},
+		},
+		{
+			name:   "logfmt",
+			format: FormatLogfmt,
+			input: `time=2020-03-04T05:06:07Z lvl=error msg="quoted \"msg\"" source=b.go:9 k=v` + "\n" +
+				"level=debug msg=plain\n" +
+				`msg="unterminated` + "\n",
+			want: []string{
+				`ERROR b.go:9 quoted "msg" orig_time=2020-03-04T05:06:07Z k=v`,
+				"DEBUG ???:1 plain",
+				`INFO ???:1 msg="unterminated`,
+			},
+		},
+		{
+			name:   "glog",
+			format: FormatGlog,
+			input: "Log file created at: 2020/01/02 15:04:05\n" +
+				"Running on machine: host\n" +
+				"I0102 15:04:05.123456    1234 main.go:10] started\n" +
+				"E0102 15:04:05.123456 1234 db.go:20] failed\n" +
+				"stack line 1\n" +
+				"W0102 15:04:05.123456 1234 x.go:3]\n",
+			want: []string{
+				"INFO main.go:10 started orig_time=" + glogTime,
+				"ERROR db.go:20 failed\nstack line 1 orig_time=" + glogTime,
+				"WARNING x.go:3  orig_time=" + glogTime,
+			},
+		},
+		{
+			name:   "glog without a header",
+			format: FormatGlog,
+			input:  "loose text\nI0102 15:04:05.123456 1 a.go:1] msg\n",
+			want: []string{
+				"INFO ???:1 loose text",
+				"INFO a.go:1 msg orig_time=" + glogTime,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &logger.Logger{}
+			l.SetFileOutput(false)
+			l.SetStderrOutput(false)
+			l.SetSeverityLimit(logger.SeverityTrace)
+			s := &importSink{}
+			l.AddSink(s)
+			defer l.Close()
+
+			n, err := Import(l, strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(tt.want) || len(s.lines) != len(tt.want) {
+				t.Fatalf("imported %d, wrote %q; want %q", n, s.lines, tt.want)
+			}
+			for i, want := range tt.want {
+				if s.lines[i] != want {
+					t.Errorf("entry %d:\n got %q\nwant %q", i, s.lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestImportUnknownFormat(t *testing.T) {
+	if _, err := Import(&logger.Logger{}, strings.NewReader(""), "xml"); err == nil {
+		t.Error("Import of an unknown format succeeded")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want logger.Severity
+	}{
+		{"trace", logger.SeverityTrace},
+		{"DBG", logger.SeverityDebug},
+		{"info", logger.SeverityInfo},
+		{"W", logger.SeverityWarning},
+		{"Warning", logger.SeverityWarning},
+		{"err", logger.SeverityError},
+		{"critical", logger.SeverityFatal},
+		{"panic", logger.SeverityFatal},
+		{"", logger.SeverityInfo},
+		{"chatty", logger.SeverityInfo},
+	}
+	for _, tt := range tests {
+		if got := parseLevel(tt.in); got != tt.want {
+			t.Errorf("parseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTime(t *testing.T) {
+	rfc := func(t time.Time) string { return t.Format(time.RFC3339Nano) }
+	tests := []struct {
+		in, want string
+	}{
+		{"2020-03-04T05:06:07.5+08:00", rfc(time.Date(2020, 3, 4, 5, 6, 7, 5e8, time.FixedZone("", 8*3600)))},
+		{"2020-03-04 05:06:07.25", rfc(time.Date(2020, 3, 4, 5, 6, 7, 25e7, time.Local))},
+		{"2020/03/04 05:06:07", rfc(time.Date(2020, 3, 4, 5, 6, 7, 0, time.Local))},
+		{"1583298367", rfc(time.Unix(1583298367, 0))},
+		{"1583298367500", rfc(time.Unix(1583298367, 5e8))},
+		{"yesterday", "yesterday"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTime(tt.in); got != tt.want {
+			t.Errorf("normalizeTime(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package reader 读取vglog写出的日志文件, 支持默认文本格式与JSON格式
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/panlibin/vglog"
+)
+
+// Entry 从日志文件读出的一条日志
+type Entry struct {
+	Time     time.Time
+	Severity logger.Severity
+	Raw      string // 原始文本, 多行日志包含其后续行, 不含结尾换行
+	JSON     bool   // 是否为JSON格式
+}
+
+// Scanner 逐条读取日志, 跳过文件头; 不以'['或'{'开头的行属于上一条日志
+type Scanner struct {
+	sc       *bufio.Scanner
+	year     int
+	month    time.Month
+	next     string
+	hasNext  bool
+	entry    Entry
+	err      error
+	prefixes []string
+	layout   string // time layout from the file header, "" for the default
+	loc      *time.Location
+	created  string // creation time from the file header, in loc
+}
+
+// NewScanner 创建Scanner
+func NewScanner(r io.Reader) *Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	s := newScanner()
+	s.sc = sc
+	return s
+}
+
+// newScanner returns a Scanner without input, for its parse state.
+func newScanner() *Scanner {
+	return &Scanner{year: time.Now().Year(), loc: time.Local}
+}
+
+// SetPrefixes 设置写入时PrefixEncoder加在日志前的前缀, 使带前缀的行仍被识别为日志的开头
+func (s *Scanner) SetPrefixes(prefixes ...string) {
+	s.prefixes = prefixes
+}
+
+// Scan 读取下一条日志, 没有更多日志或出错时返回false
+func (s *Scanner) Scan() bool {
+	first, ok := s.line()
+	for ok && !s.isEntryStart(first) {
+		first, ok = s.line() // file header or stray text
+	}
+	if !ok {
+		return false
+	}
+	// Parsed before reading on, which may run into the header of the next
+	// file.
+	s.entry = s.parse(first)
+	for {
+		line, ok := s.line()
+		if !ok {
+			break
+		}
+		if s.isEntryStart(line) {
+			s.next, s.hasNext = line, true
+			break
+		}
+		s.entry.Raw += "\n" + line
+	}
+	return true
+}
+
+// line returns the next line that is not part of a file header, noting
+// the year the file was created in.
+func (s *Scanner) line() (string, bool) {
+	if s.hasNext {
+		s.hasNext = false
+		return s.next, true
+	}
+	for s.sc.Scan() {
+		line := s.sc.Text()
+		if !s.header(line) {
+			return line, true
+		}
+	}
+	s.err = s.sc.Err()
+	return "", false
+}
+
+// header reports whether line belongs to a file header, noting what it
+// says about the file's timestamps.
+func (s *Scanner) header(line string) bool {
+	switch {
+	case strings.HasPrefix(line, "Log file created at: "):
+		// A new file starts: its format line, if any, follows.
+		s.layout, s.loc = "", time.Local
+		s.created = line[len("Log file created at: "):]
+		s.parseCreated()
+	case strings.HasPrefix(line, "Log line format: "):
+		format := line[len("Log line format: "):]
+		s.layout = headerLayout(format)
+		if strings.HasSuffix(format, " (UTC)") {
+			s.loc = time.UTC
+			s.parseCreated() // written in UTC as well
+		}
+	case strings.HasPrefix(line, "Binary: "):
+	default:
+		return false
+	}
+	return true
+}
+
+// parseCreated notes the year and month the file was created in.
+func (s *Scanner) parseCreated() {
+	if t, err := time.ParseInLocation("2006/01/02 15:04:05", s.created, s.loc); err == nil {
+		s.year, s.month = t.Year(), t.Month()
+	}
+}
+
+func (s *Scanner) isEntryStart(line string) bool {
+	line = s.trimPrefix(line)
+	return strings.HasPrefix(line, "[") || strings.HasPrefix(line, "{")
+}
+
+// trimPrefix strips the first of s.prefixes that line starts with.
+func (s *Scanner) trimPrefix(line string) string {
+	for _, p := range s.prefixes {
+		if p != "" && strings.HasPrefix(line, p) {
+			return line[len(p):]
+		}
+	}
+	return line
+}
+
+// parse extracts time and severity from an entry.
+func (s *Scanner) parse(raw string) Entry {
+	e := Entry{Raw: raw, Severity: logger.SeverityInfo}
+	raw = s.trimPrefix(raw)
+	if raw[0] == '{' {
+		e.JSON = true
+		var head struct {
+			Time  time.Time `json:"time"`
+			Level string    `json:"level"`
+		}
+		// Only the first line: the object never spans several.
+		first := raw
+		if i := strings.IndexByte(raw, '\n'); i >= 0 {
+			first = raw[:i]
+		}
+		if json.Unmarshal([]byte(first), &head) == nil {
+			e.Time = head.Time
+			if head.Level != "" {
+				if sev, err := logger.ParseSeverity(head.Level); err == nil {
+					e.Severity = sev
+				}
+			}
+		}
+		return e
+	}
+	// [<time> L file:line] msg
+	end := strings.IndexByte(raw, ']')
+	if raw[0] != '[' || end < 0 {
+		return e
+	}
+	head := raw[1:end]
+	// The severity is the first one-letter word after the time.
+	for i := 0; i+2 < len(head); i++ {
+		if head[i] != ' ' || head[i+2] != ' ' {
+			continue
+		}
+		if sev, ok := logger.SeverityByChar(head[i+1]); ok {
+			e.Severity = sev
+			e.Time = s.parseTime(head[:i])
+			break
+		}
+	}
+	return e
+}
+
+// parseTime parses the timestamp of an entry header, the zero time if it
+// doesn't match the file's layout.
+func (s *Scanner) parseTime(text string) time.Time {
+	switch s.layout {
+	case "":
+		t, err := time.ParseInLocation("01-02 15:04:05.000000", text, s.loc)
+		if err != nil {
+			return time.Time{}
+		}
+		year := s.year
+		if s.month != 0 && t.Month() < s.month {
+			year++ // the file was written into the next year
+		}
+		return t.AddDate(year-t.Year(), 0, 0)
+	case logger.TimeFormatEpochMillis:
+		ms, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(0, ms*int64(time.Millisecond))
+	}
+	t, _ := time.ParseInLocation(s.layout, text, s.loc)
+	return t
+}
+
+// headerLayout returns the time layout described by the "Log line format"
+// header line, "" for the default one.
+func headerLayout(format string) string {
+	format = strings.TrimPrefix(format, "[")
+	i := strings.Index(format, " L ")
+	if i < 0 {
+		return ""
+	}
+	switch format[:i] {
+	case "mm-dd hh:mm:ss.uuuuuu":
+		return ""
+	case "yyyy-mm-dd hh:mm:ss.uuuuuu":
+		return "2006-01-02 15:04:05.000000"
+	}
+	return format[:i]
+}
+
+// Entry 返回Scan读到的日志
+func (s *Scanner) Entry() Entry {
+	return s.entry
+}
+
+// Err 返回读取中遇到的错误
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Files 返回dir中名为name的Logger写出的s级别日志文件, 按时间先后排序; s为未注册的等级时返回错误
+func Files(dir, name string, s logger.Severity) ([]string, error) {
+	tag, err := s.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := name + "." + string(tag) + "."
+	var paths []string
+	for _, fi := range infos {
+		if fi.Mode().IsRegular() && strings.HasPrefix(fi.Name(), prefix) && strings.HasSuffix(fi.Name(), ".log") {
+			paths = append(paths, filepath.Join(dir, fi.Name()))
+		}
+	}
+	// The stamp after the tag sorts chronologically.
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ReadFile 读取一个日志文件中的所有日志
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	sc := NewScanner(f)
+	for sc.Scan() {
+		entries = append(entries, sc.Entry())
+	}
+	return entries, sc.Err()
+}
@@ -0,0 +1,259 @@
+package reader
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/panlibin/vglog"
+	"github.com/panlibin/vglog/internal/zmsg"
+)
+
+const textHeader = "Log file created at: 2020/03/04 05:06:07\n" +
+	"Binary: Built with gc go1.14 for linux/amd64\n" +
+	"Log line format: [mm-dd hh:mm:ss.uuuuuu L file:line] msg\n"
+
+func TestScanner(t *testing.T) {
+	local := func(y int, mo time.Month, d, h, mi, s, us int) time.Time {
+		return time.Date(y, mo, d, h, mi, s, us*1000, time.Local)
+	}
+	type want struct {
+		t   time.Time
+		sev logger.Severity
+		raw string
+	}
+	tests := []struct {
+		name     string
+		input    string
+		prefixes []string
+		want     []want
+	}{
+		{
+			name:  "text",
+			input: textHeader + "[03-04 05:06:07.000001 I a.go:1] one\n[03-04 05:06:08.000002 E b.go:2] two\n",
+			want: []want{
+				{local(2020, 3, 4, 5, 6, 7, 1), logger.SeverityInfo, "[03-04 05:06:07.000001 I a.go:1] one"},
+				{local(2020, 3, 4, 5, 6, 8, 2), logger.SeverityError, "[03-04 05:06:08.000002 E b.go:2] two"},
+			},
+		},
+		{
+			name:  "continuation lines",
+			input: textHeader + "[03-04 05:06:07.000001 W a.go:1] one\n  more\nand more\n[03-04 05:06:08.000000 I a.go:2] two\n",
+			want: []want{
+				{local(2020, 3, 4, 5, 6, 7, 1), logger.SeverityWarning, "[03-04 05:06:07.000001 W a.go:1] one\n  more\nand more"},
+				{local(2020, 3, 4, 5, 6, 8, 0), logger.SeverityInfo, "[03-04 05:06:08.000000 I a.go:2] two"},
+			},
+		},
+		{
+			name: "into the next year",
+			input: "Log file created at: 2020/12/31 23:59:59\n" +
+				"[12-31 23:59:59.500000 I a.go:1] old year\n[01-01 00:00:00.100000 I a.go:1] new year\n",
+			want: []want{
+				{local(2020, 12, 31, 23, 59, 59, 500000), logger.SeverityInfo, "[12-31 23:59:59.500000 I a.go:1] old year"},
+				{local(2021, 1, 1, 0, 0, 0, 100000), logger.SeverityInfo, "[01-01 00:00:00.100000 I a.go:1] new year"},
+			},
+		},
+		{
+			name: "UTC then a local file",
+			input: "Log file created at: 2020/12/31 23:59:59\n" +
+				"Log line format: [mm-dd hh:mm:ss.uuuuuu L file:line] msg (UTC)\n" +
+				"[12-31 23:59:59.000000 I a.go:1] utc\n" +
+				textHeader +
+				"[03-04 05:06:07.000000 I a.go:1] local\n",
+			want: []want{
+				{time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), logger.SeverityInfo, "[12-31 23:59:59.000000 I a.go:1] utc"},
+				{local(2020, 3, 4, 5, 6, 7, 0), logger.SeverityInfo, "[03-04 05:06:07.000000 I a.go:1] local"},
+			},
+		},
+		{
+			name: "year in the header",
+			input: "Log file created at: 2020/03/04 05:06:07\n" +
+				"Log line format: [yyyy-mm-dd hh:mm:ss.uuuuuu L file:line] msg\n" +
+				"[2019-03-04 05:06:07.000001 D a.go:1] one\n",
+			want: []want{
+				{local(2019, 3, 4, 5, 6, 7, 1), logger.SeverityDebug, "[2019-03-04 05:06:07.000001 D a.go:1] one"},
+			},
+		},
+		{
+			name: "epoch millis",
+			input: "Log file created at: 2020/03/04 05:06:07\n" +
+				"Log line format: [epoch_ms L file:line] msg\n" +
+				"[1583298367123 W a.go:1] one\n",
+			want: []want{
+				{time.Unix(1583298367, 123e6), logger.SeverityWarning, "[1583298367123 W a.go:1] one"},
+			},
+		},
+		{
+			name:  "json",
+			input: `{"time":"2020-03-04T05:06:07.000001Z","level":"ERROR","msg":"one"}` + "\n" + `{"msg":"no time or level"}` + "\n",
+			want: []want{
+				{time.Date(2020, 3, 4, 5, 6, 7, 1000, time.UTC), logger.SeverityError, `{"time":"2020-03-04T05:06:07.000001Z","level":"ERROR","msg":"one"}`},
+				{time.Time{}, logger.SeverityInfo, `{"msg":"no time or level"}`},
+			},
+		},
+		{
+			name:     "prefixes",
+			input:    textHeader + "svc-a [03-04 05:06:07.000000 E a.go:1] one\ntail\nsvc-b {\"level\":\"warning\",\"msg\":\"two\"}\n",
+			prefixes: []string{"svc-a ", "svc-b "},
+			want: []want{
+				{local(2020, 3, 4, 5, 6, 7, 0), logger.SeverityError, "svc-a [03-04 05:06:07.000000 E a.go:1] one\ntail"},
+				{time.Time{}, logger.SeverityWarning, `svc-b {"level":"warning","msg":"two"}`},
+			},
+		},
+		{
+			name:  "stray text before the first entry",
+			input: "garbage\n[03-04 05:06:07.000000 I a.go:1] one\n",
+			want: []want{
+				{local(time.Now().Year(), 3, 4, 5, 6, 7, 0), logger.SeverityInfo, "[03-04 05:06:07.000000 I a.go:1] one"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := NewScanner(strings.NewReader(tt.input))
+			sc.SetPrefixes(tt.prefixes...)
+			var got []Entry
+			for sc.Scan() {
+				got = append(got, sc.Entry())
+			}
+			if err := sc.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				e := got[i]
+				if !e.Time.Equal(w.t) || e.Severity != w.sev || e.Raw != w.raw {
+					t.Errorf("entry %d: %v %v %q, want %v %v %q", i, e.Time, e.Severity, e.Raw, w.t, w.sev, w.raw)
+				}
+				if e.JSON != strings.HasPrefix(strings.TrimPrefix(strings.TrimPrefix(w.raw, "svc-b "), "svc-a "), "{") {
+					t.Errorf("entry %d: JSON = %v", i, e.JSON)
+				}
+			}
+		})
+	}
+}
+
+// TestReadWritten reads files written by a Logger with each header
+// setting, checking the times survive.
+func TestReadWritten(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(l *logger.Logger)
+	}{
+		{"default", func(*logger.Logger) {}},
+		{"UTC", func(l *logger.Logger) { l.SetUTC(true) }},
+		{"year", func(l *logger.Logger) { l.SetHeaderYear(true) }},
+		{"RFC 3339", func(l *logger.Logger) { l.SetTimeFormat(time.RFC3339Nano) }},
+		{"epoch millis", func(l *logger.Logger) { l.SetTimeFormat(logger.TimeFormatEpochMillis) }},
+		{"json", func(l *logger.Logger) { l.SetEncoder(logger.JSONEncoder{}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "vglog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			l := &logger.Logger{}
+			l.SetLogDir(dir)
+			l.SetLogName("app")
+			l.SetStderrOutput(false)
+			tt.setup(l)
+			before := time.Now().Add(-time.Millisecond)
+			l.Warning("first")
+			l.Error("second\nspans lines")
+			after := time.Now().Add(time.Millisecond)
+			path := l.CurrentFiles()[logger.SeverityWarning]
+			l.Close()
+
+			entries, err := ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("read %d entries: %+v", len(entries), entries)
+			}
+			for i, sev := range []logger.Severity{logger.SeverityWarning, logger.SeverityError} {
+				e := entries[i]
+				if e.Severity != sev {
+					t.Errorf("entry %d: severity %v, want %v", i, e.Severity, sev)
+				}
+				if e.Time.Before(before) || e.Time.After(after) {
+					t.Errorf("entry %d: time %v not in [%v, %v]", i, e.Time, before, after)
+				}
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{
+		"app.INFO.20200102-000000.1.log",
+		"app.INFO.20200101-000000.1.log",
+		"app.INFO.20200103-000000.1.log.gz",
+		"app.WARNING.20200101-000000.1.log",
+		"other.INFO.20200101-000000.1.log",
+		"app.INFO",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Files(dir, "app", logger.SeverityInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "app.INFO.20200101-000000.1.log"),
+		filepath.Join(dir, "app.INFO.20200102-000000.1.log"),
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Files = %q, want %q", got, want)
+	}
+	if _, err := Files(dir, "app", logger.Severity(99)); err == nil {
+		t.Error("Files with an unregistered severity succeeded")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	long := strings.Repeat("a long message ", 100)
+	var z bytes.Buffer
+	zw, _ := flate.NewWriter(&z, flate.BestSpeed)
+	zw.Write([]byte(long))
+	zw.Close()
+	deflated := zmsg.Prefix + "deflate:" + base64.StdEncoding.EncodeToString(z.Bytes())
+	unknown := zmsg.Prefix + "lz4:AAAA"
+	corrupt := zmsg.Prefix + "zstd:" + base64.StdEncoding.EncodeToString([]byte("not zstd data"))
+
+	tests := []struct {
+		name, in, want string
+		wantErr        bool
+	}{
+		{"plain", "nothing compressed", "nothing compressed", false},
+		{"zstd", "msg=" + zmsg.Compress(long) + " k=v", "msg=" + long + " k=v", false},
+		{"deflate", deflated, long, false},
+		{"two markers", zmsg.Compress("one") + "," + zmsg.Compress("two"), "one,two", false},
+		{"not a marker", zmsg.Prefix + "Zstd:x " + zmsg.Prefix, zmsg.Prefix + "Zstd:x " + zmsg.Prefix, false},
+		{"unknown codec", "a " + unknown + " b", "a " + unknown + " b", true},
+		{"corrupt", corrupt + " " + zmsg.Compress("ok"), corrupt + " ok", true},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: Expand = %.60q, %v; want %.60q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
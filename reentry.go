@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// enterHook marks the calling goroutine as running user code (a sink or
+// the error handler) while it may hold l.mu. It reports whether it set the
+// mark, which must then be cleared with leaveHook.
+func (l *Logger) enterHook() bool {
+	return atomic.CompareAndSwapUint64(&l.hookOwner, 0, goid())
+}
+
+func (l *Logger) leaveHook(marked bool) {
+	if marked {
+		atomic.StoreUint64(&l.hookOwner, 0)
+	}
+}
+
+// reentrant reports whether the caller is inside one of l's hooks, where
+// taking l.mu again would deadlock. goid is only looked up while some
+// goroutine is in a hook.
+func (l *Logger) reentrant() bool {
+	owner := atomic.LoadUint64(&l.hookOwner)
+	return owner != 0 && owner == goid()
+}
+
+// writeReentrant writes an entry logged from inside a hook straight to
+// stderr, with a one-time diagnostic.
+func (l *Logger) writeReentrant(buf *buffer) {
+	n := atomic.AddUint64(&l.reentered, 1)
+	l.stderr.wmu.Lock()
+	if n == 1 {
+		fmt.Fprintf(os.Stderr, "logger: log call from inside a sink or error handler; writing such entries to stderr\n")
+	}
+	os.Stderr.Write(buf.Bytes())
+	l.stderr.wmu.Unlock()
+	_bufferPool.release(buf)
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// SinkFactory 根据配置参数创建Sink
+type SinkFactory func(params map[string]string) (Sink, error)
+
+// EncoderFactory 根据配置参数创建Encoder
+type EncoderFactory func(params map[string]string) (Encoder, error)
+
+// registry maps names used in configuration to sink and encoder factories,
+// so integrations can live in their own modules and register themselves
+// from init, the way database/sql drivers do.
+var registry = struct {
+	sync.RWMutex
+	sinks    map[string]SinkFactory
+	encoders map[string]EncoderFactory
+}{
+	sinks:    make(map[string]SinkFactory),
+	encoders: make(map[string]EncoderFactory),
+}
+
+// RegisterSink 以name注册Sink, 供配置按名称引用, 通常在init中调用; name重复时panic
+func RegisterSink(name string, factory SinkFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	if factory == nil {
+		panic("logger: RegisterSink factory is nil")
+	}
+	if _, dup := registry.sinks[name]; dup {
+		panic("logger: RegisterSink called twice for sink " + name)
+	}
+	registry.sinks[name] = factory
+}
+
+// RegisterEncoder 以name注册Encoder, 供配置按名称引用, 通常在init中调用; name重复时panic
+func RegisterEncoder(name string, factory EncoderFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	if factory == nil {
+		panic("logger: RegisterEncoder factory is nil")
+	}
+	if _, dup := registry.encoders[name]; dup {
+		panic("logger: RegisterEncoder called twice for encoder " + name)
+	}
+	registry.encoders[name] = factory
+}
+
+// NewSink 按名称创建已注册的Sink
+func NewSink(name string, params map[string]string) (Sink, error) {
+	registry.RLock()
+	factory, ok := registry.sinks[name]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("logger: unknown sink %q (forgotten import?)", name)
+	}
+	return factory(params)
+}
+
+// NewEncoder 按名称创建已注册的Encoder
+func NewEncoder(name string, params map[string]string) (Encoder, error) {
+	registry.RLock()
+	factory, ok := registry.encoders[name]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("logger: unknown encoder %q (forgotten import?)", name)
+	}
+	return factory(params)
+}
+
+// Sinks 返回已注册的Sink名称
+func Sinks() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.sinks))
+	for name := range registry.sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encoders 返回已注册的Encoder名称
+func Encoders() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.encoders))
+	for name := range registry.encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterEncoder("text", func(map[string]string) (Encoder, error) {
+		return TextEncoder{}, nil
+	})
+	RegisterSink("discard", func(map[string]string) (Sink, error) {
+		return NewDiscardSink(), nil
+	})
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetMaxAge 设置日志文件保留时长, 0表示不清理
+func (l *Logger) SetMaxAge(d time.Duration) {
+	l.mu.Lock()
+	l.maxAge = d
+	l.mu.Unlock()
+}
+
+// isActive reports whether path is one of l's currently open files.
+// l.mu is held.
+func (l *Logger) isActive(path string) bool {
+	for _, f := range l.file {
+		if sb, ok := f.(*syncBuffer); ok && sb.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup removes l's log files older than maxAge. Symlinks, preserved
+// files and the files currently being written are never removed.
+// l.mu is held.
+func (l *Logger) cleanup() {
+	if l.maxAge <= 0 {
+		return
+	}
+	dir := l.getLogDir()
+	deadline := time.Now().Add(-l.maxAge)
+	for _, fi := range l.removableFiles(dir) {
+		if !fi.ModTime().After(deadline) {
+			removeFile(filepath.Join(dir, fi.Name())) // ignore err
+		}
+	}
+}
+
+// reclaimSpace removes l's oldest removable files, whatever their age,
+// until the free space on dir is back at min. It returns the free space
+// left.
+// l.mu is held.
+func (l *Logger) reclaimSpace(dir string, free, min uint64) uint64 {
+	for _, fi := range l.removableFiles(dir) {
+		if free >= min {
+			break
+		}
+		if removeFile(filepath.Join(dir, fi.Name())) != nil {
+			continue
+		}
+		if f, err := freeSpace(dir); err == nil {
+			free = f
+		}
+	}
+	return free
+}
+
+// removableFiles returns l's finished log files in dir, oldest first,
+// without the preserved ones. It returns none if the preserve list can't
+// be read.
+// l.mu is held.
+func (l *Logger) removableFiles(dir string) []os.FileInfo {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	preserved, err := readPreserved(l.preservePath())
+	if err != nil {
+		// Without the list, any file might be evidence someone kept.
+		l.reportError(err)
+		return nil
+	}
+	prefix := l.getLogName() + "."
+	var files []os.FileInfo
+	for _, fi := range infos {
+		name := fi.Name()
+		if !fi.Mode().IsRegular() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		if !hasSeverityTag(name[len(prefix):]) {
+			continue // another Logger's files, such as a class file set
+		}
+		if _, ok := preserved[name]; ok || l.isActive(filepath.Join(dir, name)) {
+			continue
+		}
+		files = append(files, fi)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	return files
+}
+
+// hasSeverityTag reports whether rest, a file name without the log name
+// prefix, starts with a severity tag.
+func hasSeverityTag(rest string) bool {
+	for _, tag := range severityNames() {
+		if strings.HasPrefix(rest, tag+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package logger
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// retentionFixture creates files in dir, each the given age old, and
+// returns l writing there as "app".
+func retentionFixture(t *testing.T, dir string, ages map[string]time.Duration) *Logger {
+	t.Helper()
+	now := time.Now()
+	for name, age := range ages {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &Logger{}
+	t.Cleanup(func() { l.Close() })
+	l.SetLogDir(dir)
+	l.SetLogName("app")
+	l.SetStderrOutput(false)
+	return l
+}
+
+// remaining returns the sorted names left in dir.
+func remaining(t *testing.T, dir string) []string {
+	t.Helper()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range infos {
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var retentionFiles = map[string]time.Duration{
+	"app.INFO.20200101-000000.1.log":     48 * time.Hour,
+	"app.ERROR.20200102-000000.1.log":    36 * time.Hour,
+	"app.WARNING.20200103-000000.1.log":  30 * time.Minute,
+	"app.INFO.20200104-000000.2.log":     72 * time.Hour, // preserved
+	"app.pii.INFO.20200101-000000.1.log": 48 * time.Hour, // class file set
+	"other.INFO.20200101-000000.1.log":   48 * time.Hour,
+	"app.INFO.20200101-000000.1.txt":     48 * time.Hour,
+}
+
+const retentionPreserve = `{"app.INFO.20200104-000000.2.log": ["incident"]}`
+
+func TestRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		preserve string
+		reclaim  bool // reclaim space as if the disk were full
+		want     []string
+	}{
+		{
+			name: "no max age",
+			want: []string{
+				"app.ERROR.20200102-000000.1.log",
+				"app.INFO.20200101-000000.1.log",
+				"app.INFO.20200101-000000.1.txt",
+				"app.INFO.20200104-000000.2.log",
+				"app.WARNING.20200103-000000.1.log",
+				"app.pii.INFO.20200101-000000.1.log",
+				"other.INFO.20200101-000000.1.log",
+			},
+		},
+		{
+			name:   "max age",
+			maxAge: time.Hour,
+			want: []string{
+				"app.INFO.20200101-000000.1.txt",
+				"app.WARNING.20200103-000000.1.log",
+				"app.pii.INFO.20200101-000000.1.log",
+				"other.INFO.20200101-000000.1.log",
+			},
+		},
+		{
+			name:     "max age with preserve list",
+			maxAge:   time.Hour,
+			preserve: retentionPreserve,
+			want: []string{
+				"app.INFO.20200101-000000.1.txt",
+				"app.INFO.20200104-000000.2.log",
+				"app.WARNING.20200103-000000.1.log",
+				"app.pii.INFO.20200101-000000.1.log",
+				"app.preserve",
+				"other.INFO.20200101-000000.1.log",
+			},
+		},
+		{
+			name:     "unreadable preserve list",
+			maxAge:   time.Hour,
+			preserve: "{not json",
+			want: []string{
+				"app.ERROR.20200102-000000.1.log",
+				"app.INFO.20200101-000000.1.log",
+				"app.INFO.20200101-000000.1.txt",
+				"app.INFO.20200104-000000.2.log",
+				"app.WARNING.20200103-000000.1.log",
+				"app.pii.INFO.20200101-000000.1.log",
+				"app.preserve",
+				"other.INFO.20200101-000000.1.log",
+			},
+		},
+		{
+			name:     "reclaim space without max age",
+			preserve: retentionPreserve,
+			reclaim:  true,
+			want: []string{
+				"app.INFO.20200101-000000.1.txt",
+				"app.INFO.20200104-000000.2.log",
+				"app.pii.INFO.20200101-000000.1.log",
+				"app.preserve",
+				"other.INFO.20200101-000000.1.log",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "vglog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			l := retentionFixture(t, dir, retentionFiles)
+			if tt.preserve != "" {
+				if err := ioutil.WriteFile(filepath.Join(dir, "app.preserve"), []byte(tt.preserve), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var errs int
+			l.SetErrorHandler(func(error) { errs++ })
+			l.SetMaxAge(tt.maxAge)
+
+			l.mu.Lock()
+			if tt.reclaim {
+				l.reclaimSpace(dir, 0, math.MaxUint64)
+			} else {
+				l.cleanup()
+			}
+			l.mu.Unlock()
+
+			if got := remaining(t, dir); !equalNames(got, tt.want) {
+				t.Errorf("left %q, want %q", got, tt.want)
+			}
+			if wantErrs := tt.preserve == "{not json"; (errs > 0) != wantErrs {
+				t.Errorf("%d errors reported", errs)
+			}
+		})
+	}
+}
+
+func TestRetentionKeepsActiveFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := retentionFixture(t, dir, map[string]time.Duration{
+		"app.INFO.20200101-000000.1.log": 48 * time.Hour,
+	})
+	l.Info("opens the INFO file")
+	l.Flush()
+	active := l.CurrentFiles()[SeverityInfo]
+	old := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes(active, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	l.mu.Lock()
+	files := l.removableFiles(dir)
+	l.reclaimSpace(dir, 0, math.MaxUint64)
+	l.mu.Unlock()
+
+	if len(files) != 1 || files[0].Name() != "app.INFO.20200101-000000.1.log" {
+		t.Errorf("removable files: %v", files)
+	}
+	if _, err := os.Stat(active); err != nil {
+		t.Errorf("active file removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.INFO.20200101-000000.1.log")); !os.IsNotExist(err) {
+		t.Errorf("old file kept: %v", err)
+	}
+}
+
+func TestRemovableFilesOldestFirst(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l := retentionFixture(t, dir, retentionFiles)
+
+	l.mu.Lock()
+	files := l.removableFiles(dir)
+	l.mu.Unlock()
+
+	want := []string{
+		"app.INFO.20200104-000000.2.log",
+		"app.INFO.20200101-000000.1.log",
+		"app.ERROR.20200102-000000.1.log",
+		"app.WARNING.20200103-000000.1.log",
+	}
+	var got []string
+	for _, fi := range files {
+		got = append(got, fi.Name())
+	}
+	if !equalNames(got, want) {
+		t.Errorf("removable files %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// SetRotateHook 设置文件轮转后的回调, path为已关闭的文件; fn在单独的goroutine中执行, Close等待其返回, 为nil时关闭
+func (l *Logger) SetRotateHook(fn func(s Severity, path string)) {
+	l.mu.Lock()
+	l.rotateHook = fn
+	l.mu.Unlock()
+}
+
+// SetRotateCommand 设置文件轮转后执行的外部命令, 已关闭文件的路径作为最后一个参数;
+// 超过timeout(>0时)的命令被终止, 失败通过错误回调报告. name为空时关闭
+func (l *Logger) SetRotateCommand(timeout time.Duration, name string, args ...string) {
+	if name == "" {
+		l.SetRotateHook(nil)
+		return
+	}
+	args = append([]string(nil), args...)
+	l.SetRotateHook(func(s Severity, path string) {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		out, err := exec.CommandContext(ctx, name, append(args, path)...).CombinedOutput()
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %v", timeout)
+		}
+		if err != nil {
+			l.reportError(fmt.Errorf("rotate command %s %s: %v: %s", name, path, err, bytes.TrimSpace(out)))
+		}
+	})
+}
+
+// runRotateHook hands the file closed by a rotation to the hook.
+// l.mu is held.
+func (l *Logger) runRotateHook(s Severity, path string) {
+	if fn := l.rotateHook; fn != nil {
+		l.daemons.spawn(func(<-chan struct{}) { fn(s, path) })
+	}
+}
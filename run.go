@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runHeartbeat is how often a running Logger refreshes its run marker, so
+// the duration of a run that crashed is known to that precision.
+const runHeartbeat = 30 * time.Second
+
+// RunSummary 上次运行的摘要, 由StartRun从标记文件读出
+type RunSummary struct {
+	Found     bool          // 存在上次运行的标记文件
+	Clean     bool          // 上次运行以Close正常退出
+	Pid       int           // 上次运行的进程号
+	Start     time.Time     // 上次运行的开始时间
+	Duration  time.Duration // 上次运行的时长, 异常退出时截至最后一次心跳
+	LastError string        // 上次运行最后一条Error及以上级别的日志
+	Preserved []string      // 上次运行异常退出时被保留、不受清理的日志文件名
+}
+
+// runMarker is the content of the <name>.run file.
+type runMarker struct {
+	Pid       int        `json:"pid"`
+	Start     time.Time  `json:"start"`
+	Seen      time.Time  `json:"seen"`
+	Stop      *time.Time `json:"stop,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// runState tracks the current run's marker file.
+type runState struct {
+	mu    sync.Mutex
+	path  string
+	m     runMarker
+	saved time.Time
+}
+
+// save rewrites the marker file through a temporary file, so a crash
+// mid-write leaves the previous content.
+// r.mu is held.
+func (r *runState) save() error {
+	r.saved = time.Now()
+	data, err := json.Marshal(r.m)
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return renameFile(tmp, r.path)
+}
+
+// StartRun 记录本次运行开始: 读取上次运行留下的标记文件<日志目录>/<日志名>.run, 写一条启动摘要
+// (上次正常退出为Info, 异常退出为Warning), 包括上次运行时长与最后一条错误, 可据此发现反复崩溃;
+// 上次异常退出时其日志文件加入保留列表, 不被SetMaxAge清理, 以便事后查看;
+// 然后写入本次的标记文件, 运行中定时更新, Close时记为正常退出
+func (l *Logger) StartRun() (RunSummary, error) {
+	l.mu.Lock()
+	path := filepath.Join(l.getLogDir(), l.getLogName()+".run")
+	l.mu.Unlock()
+
+	var sum RunSummary
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var prev runMarker
+		if json.Unmarshal(data, &prev) == nil {
+			end := prev.Seen
+			if prev.Stop != nil {
+				end = *prev.Stop
+			}
+			sum = RunSummary{
+				Found:     true,
+				Clean:     prev.Stop != nil,
+				Pid:       prev.Pid,
+				Start:     prev.Start,
+				Duration:  end.Sub(prev.Start),
+				LastError: prev.LastError,
+			}
+		}
+	}
+
+	if sum.Found && !sum.Clean {
+		l.mu.Lock()
+		sum.Preserved = l.runFiles(sum.Pid, sum.Start, sum.Start.Add(sum.Duration))
+		err := l.preserveFiles(sum.Preserved, "unclean shutdown of pid "+strconv.Itoa(sum.Pid))
+		l.mu.Unlock()
+		if err != nil {
+			l.reportError(err)
+		}
+	}
+
+	now := time.Now()
+	r := &runState{path: path, m: runMarker{Pid: os.Getpid(), Start: now, Seen: now}}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return sum, err
+	}
+	r.mu.Lock()
+	err := r.save()
+	r.mu.Unlock()
+	if err != nil {
+		return sum, err
+	}
+	l.run.Store(r)
+	l.daemons.spawn(func(stop <-chan struct{}) { l.runDaemon(r, stop) })
+	l.logRunSummary(sum)
+	return sum, nil
+}
+
+// logRunSummary writes the startup summary of sum.
+func (l *Logger) logRunSummary(sum RunSummary) {
+	e := &Entry{
+		Severity: SeverityInfo,
+		Time:     time.Now(),
+		File:     "run",
+		Message:  "first run",
+	}
+	if sum.Found {
+		e.Message = "previous run ended cleanly"
+		if !sum.Clean {
+			e.Severity = SeverityWarning
+			e.Message = "previous run ended uncleanly"
+		}
+		e.Fields = []Field{
+			{Key: "prev_pid", Value: strconv.Itoa(sum.Pid)},
+			{Key: "prev_start", Value: sum.Start.Format(time.RFC3339)},
+			{Key: "prev_duration", Value: sum.Duration.Round(time.Second).String()},
+		}
+		if sum.LastError != "" {
+			e.Fields = append(e.Fields, Field{Key: "prev_last_error", Value: sum.LastError})
+		}
+		if len(sum.Preserved) > 0 {
+			e.Fields = append(e.Fields, Field{Key: "preserved", Value: strings.Join(sum.Preserved, ",")})
+		}
+	}
+	if l.enabled(e.Severity) {
+		l.log(e)
+	}
+}
+
+// runDaemon refreshes the marker's heartbeat until the Logger closes.
+func (l *Logger) runDaemon(r *runState, stop <-chan struct{}) {
+	ticker := time.NewTicker(runHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			if r.m.Stop == nil {
+				r.m.Seen = now
+				r.save() // ignore err
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (l *Logger) runState() *runState {
+	r, _ := l.run.Load().(*runState)
+	return r
+}
+
+// noteRunError records e as the run's last error. The marker is rewritten
+// at most once a second, except for Fatal entries that end the run.
+func (r *runState) noteRunError(e *Entry) {
+	msg := e.Message
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	r.mu.Lock()
+	r.m.LastError = e.Severity.name() + " " + e.File + ":" + strconv.Itoa(e.Line) + "] " + msg
+	if e.Severity.atLeast(SeverityFatal) || time.Since(r.saved) >= time.Second {
+		r.m.Seen = time.Now()
+		r.save() // ignore err
+	}
+	r.mu.Unlock()
+}
+
+// endRun marks the run as ended cleanly.
+func (l *Logger) endRun() {
+	r := l.runState()
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	now := time.Now()
+	r.m.Seen, r.m.Stop = now, &now
+	if err := r.save(); err != nil {
+		l.reportError(err)
+	}
+	r.mu.Unlock()
+}
+
+// StartRun 默认logger快捷调用
+func StartRun() (RunSummary, error) {
+	return DefaultLogger.StartRun()
+}
@@ -0,0 +1,307 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxSeverities bounds the built-in and registered severities, sizing the
+// per-severity tables such as Logger.file.
+const maxSeverities = 32
+
+// rankStep spaces the ranks of the built-in severities, leaving room to
+// register custom ones between them.
+const rankStep = 1 << 8
+
+// severityInfo describes one severity.
+type severityInfo struct {
+	name string
+	char byte
+	rank int32 // ordering; built-in severities have int(s)*rankStep
+}
+
+// severityTable is the copy-on-write set of known severities, indexed by
+// Severity.index.
+type severityTable struct {
+	info   []severityInfo
+	chars  string     // info[i].char for every i
+	byRank []Severity // all severities, lowest first
+}
+
+var (
+	severities   atomic.Value // *severityTable
+	severitiesMu sync.Mutex   // serializes RegisterSeverity
+)
+
+func init() {
+	t := &severityTable{}
+	for i, name := range []string{"TRACE", "DEBUG", "INFO", "WARNING", "ERROR", "FATAL"} {
+		s := SeverityTrace + Severity(i)
+		t.info = append(t.info, severityInfo{name: name, char: name[0], rank: int32(s) * rankStep})
+		t.byRank = append(t.byRank, s)
+	}
+	t.chars = "TDIWEF"
+	severities.Store(t)
+}
+
+func severityTab() *severityTable {
+	return severities.Load().(*severityTable)
+}
+
+// RegisterSeverity 注册自定义日志等级, 如NOTICE、AUDIT: name为文件名中的等级标签, char为日志行中的等级字符,
+// 新等级排在after之上、原先紧邻after的等级之下, 拥有独立的日志文件; 应在写日志前调用
+func RegisterSeverity(name string, char byte, after Severity) (Severity, error) {
+	severitiesMu.Lock()
+	defer severitiesMu.Unlock()
+	old := severityTab()
+	if name == "" || strings.ContainsAny(name, "./\\ \t\n") {
+		return 0, fmt.Errorf("logger: invalid severity name %q", name)
+	}
+	if char <= ' ' || char > '~' || char == '[' || char == '{' {
+		return 0, fmt.Errorf("logger: invalid severity char %q", char)
+	}
+	for _, si := range old.info {
+		if strings.EqualFold(si.name, name) || si.char == char {
+			return 0, fmt.Errorf("logger: severity %q or char %q already registered", name, char)
+		}
+	}
+	if !after.valid() {
+		return 0, fmt.Errorf("logger: unknown severity %d", after)
+	}
+	if len(old.info) == maxSeverities {
+		return 0, fmt.Errorf("logger: too many severities")
+	}
+	lo := after.rank()
+	hi := lo + rankStep
+	pos := 0
+	for i, s := range old.byRank {
+		if s == after {
+			pos = i + 1
+			if pos < len(old.byRank) {
+				hi = old.byRank[pos].rank()
+			}
+		}
+	}
+	if hi-lo < 2 {
+		return 0, fmt.Errorf("logger: no room for another severity above %s", after.name())
+	}
+	s := SeverityTrace + Severity(len(old.info))
+	t := &severityTable{
+		info:   append(old.info[:len(old.info):len(old.info)], severityInfo{name: name, char: char, rank: lo + (hi-lo)/2}),
+		chars:  old.chars + string(char),
+		byRank: make([]Severity, 0, len(old.byRank)+1),
+	}
+	t.byRank = append(t.byRank, old.byRank[:pos]...)
+	t.byRank = append(t.byRank, s)
+	t.byRank = append(t.byRank, old.byRank[pos:]...)
+	severities.Store(t)
+	return s, nil
+}
+
+// index returns the position of s in per-severity tables, which start at
+// SeverityTrace.
+func (s Severity) index() int {
+	return int(s - SeverityTrace)
+}
+
+// valid reports whether s is a built-in or registered severity.
+func (s Severity) valid() bool {
+	return s >= SeverityTrace && s.index() < len(severityTab().info)
+}
+
+// name returns the severity's name, or INFO for unknown values.
+func (s Severity) name() string {
+	if !s.valid() {
+		s = SeverityInfo
+	}
+	return severityTab().info[s.index()].name
+}
+
+// char returns the letter of s in the text format.
+func (s Severity) char() byte {
+	if !s.valid() {
+		s = SeverityInfo
+	}
+	return severityTab().info[s.index()].char
+}
+
+// rank returns the ordering key of s; unknown values rank as Info.
+func (s Severity) rank() int32 {
+	if !s.valid() {
+		s = SeverityInfo
+	}
+	return severityTab().info[s.index()].rank
+}
+
+// atLeast reports whether s ranks at or above t.
+func (s Severity) atLeast(t Severity) bool {
+	return s.rank() >= t.rank()
+}
+
+// builtin returns the highest built-in severity ranking at or below s, for
+// outputs that only know the built-in levels.
+func (s Severity) builtin() Severity {
+	if s >= SeverityTrace && s < severityCount {
+		return s
+	}
+	r := s.rank()
+	b := SeverityTrace
+	for c := SeverityDebug; c < severityCount; c++ {
+		if c.rank() <= r {
+			b = c
+		}
+	}
+	return b
+}
+
+// span returns the part of t.byRank ranking from lo up to hi.
+func (t *severityTable) span(lo, hi Severity) []Severity {
+	rlo, rhi := t.rankOf(lo), t.rankOf(hi)
+	i, j := 0, len(t.byRank)
+	for i < j && t.rankOf(t.byRank[i]) < rlo {
+		i++
+	}
+	for j > i && t.rankOf(t.byRank[j-1]) > rhi {
+		j--
+	}
+	return t.byRank[i:j]
+}
+
+func (t *severityTable) rankOf(s Severity) int32 {
+	if s < SeverityTrace || s.index() >= len(t.info) {
+		s = SeverityInfo
+	}
+	return t.info[s.index()].rank
+}
+
+// severityNames returns the names of all severities, by index.
+func severityNames() []string {
+	t := severityTab()
+	names := make([]string, len(t.info))
+	for i, si := range t.info {
+		names[i] = si.name
+	}
+	return names
+}
+
+// errUnknownSeverity reports a severity that is neither built in nor
+// registered.
+func errUnknownSeverity(s Severity) error {
+	return fmt.Errorf("logger: unregistered severity %d", int(s))
+}
+
+// checkSeverity reports whether s can be logged, reporting the error if
+// it can't.
+func (l *Logger) checkSeverity(s Severity) bool {
+	if s.valid() {
+		return true
+	}
+	l.reportError(errUnknownSeverity(s))
+	return false
+}
+
+// Log 以等级s写日志, 用于自定义等级; s未注册时经错误回调报告, 不写日志
+func (l *Logger) Log(s Severity, args ...interface{}) {
+	if l.checkSeverity(s) {
+		l.println(s, args...)
+	}
+}
+
+// Logf 以等级s写格式化日志, 用于自定义等级; s未注册时经错误回调报告, 不写日志
+func (l *Logger) Logf(s Severity, format string, args ...interface{}) {
+	if l.checkSeverity(s) {
+		l.printf(s, format, args...)
+	}
+}
+
+// Log 默认logger快捷调用
+func Log(s Severity, args ...interface{}) {
+	if DefaultLogger.checkSeverity(s) {
+		DefaultLogger.println(s, args...)
+	}
+}
+
+// Logf 默认logger快捷调用
+func Logf(s Severity, format string, args ...interface{}) {
+	if DefaultLogger.checkSeverity(s) {
+		DefaultLogger.printf(s, format, args...)
+	}
+}
+
+// ParseSeverity 按名称解析日志等级, 不区分大小写, 含自定义等级; 另接受"WARN"、"ERR"及数值
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToUpper(name) {
+	case "WARN":
+		return SeverityWarning, nil
+	case "ERR":
+		return SeverityError, nil
+	}
+	for i, si := range severityTab().info {
+		if strings.EqualFold(si.name, name) {
+			return SeverityTrace + Severity(i), nil
+		}
+	}
+	if n, err := strconv.ParseInt(name, 10, 32); err == nil && Severity(n).valid() {
+		return Severity(n), nil
+	}
+	return SeverityInfo, fmt.Errorf("logger: unknown severity %q", name)
+}
+
+// SeverityByChar 返回文本格式中等级字符c对应的日志等级, 含自定义等级
+func SeverityByChar(c byte) (Severity, bool) {
+	if i := strings.IndexByte(severityTab().chars, c); i >= 0 {
+		return SeverityTrace + Severity(i), true
+	}
+	return SeverityInfo, false
+}
+
+// String 返回等级名称, 如"WARNING"
+func (s Severity) String() string {
+	if !s.valid() {
+		return "Severity(" + strconv.Itoa(int(s)) + ")"
+	}
+	return s.name()
+}
+
+// Set 实现flag.Value, 按ParseSeverity解析
+func (s *Severity) Set(value string) error {
+	v, err := ParseSeverity(value)
+	if err != nil {
+		return err
+	}
+	s.set(v)
+	return nil
+}
+
+// Get 实现flag.Getter
+func (s *Severity) Get() interface{} {
+	return s.get()
+}
+
+// MarshalText 实现encoding.TextMarshaler, 输出等级名称
+func (s Severity) MarshalText() ([]byte, error) {
+	if !s.valid() {
+		return nil, fmt.Errorf("logger: invalid severity %d", int32(s))
+	}
+	return []byte(s.name()), nil
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler, 按ParseSeverity解析
+func (s *Severity) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
+// UnmarshalJSON 接受等级名称, 也接受旧配置中的等级数值
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		name, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		return s.Set(name)
+	}
+	return s.Set(string(data))
+}
@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testSeverity registers name once per test binary, so tests still pass
+// with -count > 1.
+func testSeverity(t *testing.T, name string, char byte, after Severity) Severity {
+	t.Helper()
+	if s, err := ParseSeverity(name); err == nil {
+		return s
+	}
+	s, err := RegisterSeverity(name, char, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{"TRACE", SeverityTrace, false},
+		{"debug", SeverityDebug, false},
+		{"Info", SeverityInfo, false},
+		{"WARNING", SeverityWarning, false},
+		{"warn", SeverityWarning, false},
+		{"ERR", SeverityError, false},
+		{"error", SeverityError, false},
+		{"FATAL", SeverityFatal, false},
+		{"2", SeverityWarning, false},
+		{"-1", SeverityTrace, false},
+		{"", SeverityInfo, true},
+		{"verbose", SeverityInfo, true},
+		{"99", SeverityInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSeverity(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSeverityText(t *testing.T) {
+	for _, s := range severityTab().byRank {
+		text, err := s.MarshalText()
+		if err != nil {
+			t.Fatalf("%v.MarshalText: %v", s, err)
+		}
+		var back Severity
+		if err := back.UnmarshalText(text); err != nil || back != s {
+			t.Errorf("UnmarshalText(%q) = %v, %v; want %v", text, back, err, s)
+		}
+	}
+	if _, err := Severity(99).MarshalText(); err == nil {
+		t.Error("MarshalText of an unregistered severity succeeded")
+	}
+	if got := Severity(99).String(); got != "Severity(99)" {
+		t.Errorf("String of an unregistered severity = %q", got)
+	}
+
+	tests := []struct {
+		in   string
+		want Severity
+	}{
+		{`"ERROR"`, SeverityError},
+		{`"warn"`, SeverityWarning},
+		{`3`, SeverityError}, // numeric values from old configs
+	}
+	for _, tt := range tests {
+		var s Severity
+		if err := json.Unmarshal([]byte(tt.in), &s); err != nil || s != tt.want {
+			t.Errorf("json.Unmarshal(%s) = %v, %v; want %v", tt.in, s, err, tt.want)
+		}
+	}
+}
+
+func TestRegisterSeverity(t *testing.T) {
+	notice := testSeverity(t, "NOTICE", 'N', SeverityInfo)
+	audit := testSeverity(t, "AUDIT", 'A', SeverityInfo)
+
+	if !notice.valid() || !audit.valid() {
+		t.Fatal("registered severities are not valid")
+	}
+	// AUDIT went above INFO, below NOTICE which was there first.
+	order := []Severity{SeverityInfo, audit, notice, SeverityWarning}
+	for i := 1; i < len(order); i++ {
+		if order[i].rank() <= order[i-1].rank() {
+			t.Errorf("%v ranks at or below %v", order[i], order[i-1])
+		}
+	}
+	if !notice.atLeast(SeverityInfo) || notice.atLeast(SeverityWarning) {
+		t.Error("NOTICE isn't between INFO and WARNING")
+	}
+	if got := notice.builtin(); got != SeverityInfo {
+		t.Errorf("NOTICE.builtin() = %v, want INFO", got)
+	}
+	if s, ok := SeverityByChar('N'); !ok || s != notice {
+		t.Errorf("SeverityByChar('N') = %v, %v", s, ok)
+	}
+	if s, err := ParseSeverity("notice"); err != nil || s != notice {
+		t.Errorf("ParseSeverity(notice) = %v, %v", s, err)
+	}
+	if text, _ := notice.MarshalText(); string(text) != "NOTICE" {
+		t.Errorf("NOTICE.MarshalText() = %q", text)
+	}
+	span := severityTab().span(SeverityInfo, SeverityWarning)
+	if len(span) != 4 || span[0] != SeverityInfo || span[3] != SeverityWarning {
+		t.Errorf("span(INFO, WARNING) = %v", span)
+	}
+
+	bad := []struct {
+		name  string
+		char  byte
+		after Severity
+	}{
+		{"", 'X', SeverityInfo},
+		{"a.b", 'X', SeverityInfo},
+		{"has space", 'X', SeverityInfo},
+		{"X1", ' ', SeverityInfo},
+		{"X2", '[', SeverityInfo},
+		{"notice", 'X', SeverityInfo}, // name taken, case-insensitively
+		{"X3", 'I', SeverityInfo},     // char taken
+		{"X4", 'X', Severity(99)},
+	}
+	for _, tt := range bad {
+		if s, err := RegisterSeverity(tt.name, tt.char, tt.after); err == nil {
+			t.Errorf("RegisterSeverity(%q, %q, %v) = %v, want error", tt.name, tt.char, tt.after, s)
+		}
+	}
+}
+
+func TestLogUnregisteredSeverity(t *testing.T) {
+	l := &Logger{}
+	defer l.Close()
+	l.SetFileOutput(false)
+	l.SetStderrOutput(false)
+	var errs []error
+	l.SetErrorHandler(func(err error) { errs = append(errs, err) })
+	l.Log(Severity(99), "dropped")
+	if len(errs) != 1 {
+		t.Errorf("got %d errors for an unregistered severity, want 1", len(errs))
+	}
+}
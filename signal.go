@@ -0,0 +1,37 @@
+package logger
+
+// stepSeverity moves the severity limit one severity down (more verbose)
+// or up along the ranking and logs the change, returning the new limit.
+func (l *Logger) stepSeverity(up bool) Severity {
+	order := severityTab().byRank
+	old := l.severityLimit.get()
+	pos := 0
+	for i, s := range order {
+		if s == old {
+			pos = i
+		}
+	}
+	switch {
+	case up && pos+1 < len(order):
+		pos++
+	case !up && pos > 0:
+		pos--
+	}
+	limit := order[pos]
+	if limit == old {
+		return limit
+	}
+	l.SetSeverityLimit(limit)
+	// Log at the new limit when it is above Info, so the note isn't dropped.
+	s := SeverityInfo
+	if limit.atLeast(s) {
+		s = limit
+	}
+	l.Logf(s, "severity limit changed by signal: %s -> %s", old, limit)
+	return limit
+}
+
+// HandleSignals 默认logger快捷调用
+func HandleSignals() error {
+	return DefaultLogger.HandleSignals()
+}
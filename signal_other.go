@@ -0,0 +1,11 @@
+//go:build windows || js || plan9
+// +build windows js plan9
+
+package logger
+
+import "errors"
+
+// HandleSignals 开启信号控制, 本平台没有SIGUSR1/SIGUSR2, 总是返回错误
+func (l *Logger) HandleSignals() error {
+	return errors.New("logger: severity signals not supported on this platform")
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !js && !plan9
+// +build !windows,!js,!plan9
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals 开启信号控制: SIGUSR1降低日志级别一级(更详细), SIGUSR2提高一级, 变更写入日志; Close时停止
+func (l *Logger) HandleSignals() error {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	if !l.daemons.spawn(func(stop <-chan struct{}) {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-ch:
+				l.stepSeverity(sig == syscall.SIGUSR2)
+			}
+		}
+	}) {
+		signal.Stop(ch)
+		return errors.New("logger: HandleSignals called after Close")
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Sink 日志输出目标, 与日志文件并行接收所有写出的日志条目
+type Sink interface {
+	WriteEntry(e *Entry) error
+	Flush() error
+}
+
+// addedSink is a sink added to a Logger with its settings. Sinks are
+// never map keys, as their dynamic type may not be hashable.
+type addedSink struct {
+	sink         Sink
+	threshold    Severity
+	hasThreshold bool
+	config       *SinkConfig // how Configure built it; nil for AddSink
+}
+
+// sameSink reports whether a and b are the same sink. Sinks of types
+// that can't be compared with == are the same if they share their
+// func, map or slice.
+func sameSink(a, b Sink) (same bool) {
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) {
+		return false
+	}
+	if ta == nil {
+		return true
+	}
+	if !ta.Comparable() {
+		switch ta.Kind() {
+		case reflect.Func, reflect.Map, reflect.Slice:
+			return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+		}
+		return false
+	}
+	// A comparable type may still hold an uncomparable value in an
+	// interface field, which makes == panic.
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// findSink returns the settings of sink, nil if it wasn't added.
+// l.mu is held.
+func (l *Logger) findSink(sink Sink) *addedSink {
+	for _, as := range l.sinks {
+		if sameSink(as.sink, sink) {
+			return as
+		}
+	}
+	return nil
+}
+
+// AddSink 添加日志输出目标
+func (l *Logger) AddSink(sink Sink) {
+	l.mu.Lock()
+	l.sinks = append(l.sinks, &addedSink{sink: sink})
+	l.updateSinkFloor()
+	l.mu.Unlock()
+}
+
+// RemoveSink 移除日志输出目标
+func (l *Logger) RemoveSink(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, as := range l.sinks {
+		if sameSink(as.sink, sink) {
+			l.sinks = append(l.sinks[:i:i], l.sinks[i+1:]...)
+			l.updateSinkFloor()
+			return
+		}
+	}
+}
+
+// SetSinkThreshold 设置sink的最低日志级别, 与SetSeverityLimit相互独立;
+// 未设置时sink沿用SetSeverityLimit. sink须已通过AddSink添加
+func (l *Logger) SetSinkThreshold(sink Sink, s Severity) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if as := l.findSink(sink); as != nil {
+		as.threshold, as.hasThreshold = s, true
+		l.updateSinkFloor()
+	}
+}
+
+// SinkThreshold 返回sink的最低日志级别, 未设置时ok为false, 此时sink沿用SetSeverityLimit
+func (l *Logger) SinkThreshold(sink Sink) (s Severity, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if as := l.findSink(sink); as != nil && as.hasThreshold {
+		return as.threshold, true
+	}
+	return 0, false
+}
+
+// ClearSinkThreshold 取消SetSinkThreshold, sink重新沿用SetSeverityLimit
+func (l *Logger) ClearSinkThreshold(sink Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if as := l.findSink(sink); as != nil {
+		as.hasThreshold = false
+		l.updateSinkFloor()
+	}
+}
+
+// updateSinkFloor recomputes the lowest threshold of the added sinks
+// and stderr that have one, stored as its index+1 so zero means none.
+// l.mu is held.
+func (l *Logger) updateSinkFloor() {
+	floor := int32(0)
+	if l.stderrLevelSet && !l.noStderr {
+		floor = int32(l.stderrLevel.index()) + 1
+	}
+	for _, as := range l.sinks {
+		if s := as.threshold; as.hasThreshold && (floor == 0 || !s.atLeast(Severity(floor-1)+SeverityTrace)) {
+			floor = int32(s.index()) + 1
+		}
+	}
+	atomic.StoreInt32(&l.sinkFloor, floor)
+}
+
+// sinkWants reports whether some sink threshold admits s.
+func (l *Logger) sinkWants(s Severity) bool {
+	floor := atomic.LoadInt32(&l.sinkFloor)
+	return floor != 0 && s.atLeast(Severity(floor-1)+SeverityTrace)
+}
+
+// writeSinks hands e to every sink whose threshold admits it.
+// l.mu is held.
+func (l *Logger) writeSinks(e *Entry) {
+	if len(l.sinks) == 0 {
+		return
+	}
+	marked := l.enterHook()
+	defer l.leaveHook(marked)
+	slimit := l.severityLimit.get()
+	for _, as := range l.sinks {
+		sink := as.sink
+		threshold := as.threshold
+		if !as.hasThreshold {
+			threshold = slimit
+			if e.targeted {
+				threshold = SeverityDebug
+			}
+		}
+		if !e.Severity.atLeast(threshold) {
+			continue
+		}
+		se := e
+		if ps, ok := sink.(PrivacySink); ok {
+			se = e.sanitized(ps.MaxPrivacy())
+		}
+		if err := sink.WriteEntry(se); err != nil {
+			l.reportError(err)
+		}
+	}
+}
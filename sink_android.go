@@ -0,0 +1,68 @@
+//go:build android && cgo
+// +build android,cgo
+
+package logger
+
+/*
+#cgo LDFLAGS: -llog
+#include <android/log.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"unsafe"
+)
+
+// LogcatSink 将日志写入Android logcat
+type LogcatSink struct {
+	tag *C.char
+}
+
+// NewLogcatSink 创建写入logcat的Sink, tag为logcat标签
+func NewLogcatSink(tag string) *LogcatSink {
+	return &LogcatSink{tag: C.CString(tag)}
+}
+
+// logcatPriority maps a severity onto the logcat priorities.
+func logcatPriority(s Severity) C.int {
+	switch s = s.builtin(); {
+	case s <= SeverityTrace:
+		return C.ANDROID_LOG_VERBOSE
+	case s == SeverityDebug:
+		return C.ANDROID_LOG_DEBUG
+	case s == SeverityInfo:
+		return C.ANDROID_LOG_INFO
+	case s == SeverityWarning:
+		return C.ANDROID_LOG_WARN
+	case s >= SeverityFatal:
+		return C.ANDROID_LOG_FATAL
+	}
+	return C.ANDROID_LOG_ERROR
+}
+
+// WriteEntry 实现Sink
+func (lc *LogcatSink) WriteEntry(e *Entry) error {
+	msg := C.CString(e.File + ":" + strconv.Itoa(e.Line) + "] " + e.Text())
+	C.__android_log_write(logcatPriority(e.Severity), lc.tag, msg)
+	C.free(unsafe.Pointer(msg))
+	return nil
+}
+
+// Flush 实现Sink, logcat不缓冲
+func (lc *LogcatSink) Flush() error {
+	return nil
+}
+
+// Android apps have no usable directory beside the binary, so the default
+// logger writes to logcat instead of files.
+func init() {
+	RegisterSink("logcat", func(params map[string]string) (Sink, error) {
+		return NewLogcatSink(params["tag"]), nil
+	})
+	DefaultLogger.SetFileOutput(false)
+	DefaultLogger.AddSink(NewLogcatSink(filepath.Base(os.Args[0])))
+}
@@ -0,0 +1,102 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package logger
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+// os_log_with_type is a macro that needs a literal format string.
+static void vglog_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"strconv"
+	"sync"
+	"unsafe"
+)
+
+// OSLogCategoryKey 日志字段, 用于指定OSLogSink写入的category
+const OSLogCategoryKey = "category"
+
+// OSLogSink 将日志写入macOS统一日志系统(os_log), 在Console.app中可见
+type OSLogSink struct {
+	subsystem string
+	category  string
+	mu        sync.Mutex
+	logs      map[string]C.os_log_t
+}
+
+// NewOSLogSink 创建OSLogSink, subsystem如"com.example.agent"; 条目带有category字段时写入对应category, 否则写入category
+func NewOSLogSink(subsystem, category string) *OSLogSink {
+	return &OSLogSink{
+		subsystem: subsystem,
+		category:  category,
+		logs:      make(map[string]C.os_log_t),
+	}
+}
+
+// osLogType maps a severity onto the unified logging levels.
+func osLogType(s Severity) C.os_log_type_t {
+	switch s = s.builtin(); {
+	case s <= SeverityDebug:
+		return C.OS_LOG_TYPE_DEBUG
+	case s == SeverityInfo:
+		return C.OS_LOG_TYPE_INFO
+	case s == SeverityWarning:
+		return C.OS_LOG_TYPE_DEFAULT
+	case s >= SeverityFatal:
+		return C.OS_LOG_TYPE_FAULT
+	}
+	return C.OS_LOG_TYPE_ERROR
+}
+
+// logFor returns the os_log handle for category, creating it on first use.
+// Handles are never released, as os_log expects.
+func (o *OSLogSink) logFor(category string) C.os_log_t {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if log, ok := o.logs[category]; ok {
+		return log
+	}
+	cs := C.CString(o.subsystem)
+	cc := C.CString(category)
+	log := C.os_log_create(cs, cc)
+	C.free(unsafe.Pointer(cs))
+	C.free(unsafe.Pointer(cc))
+	o.logs[category] = log
+	return log
+}
+
+// WriteEntry 实现Sink
+func (o *OSLogSink) WriteEntry(e *Entry) error {
+	category := o.category
+	fields := make([]Field, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		if f.Key == OSLogCategoryKey {
+			category = f.String()
+			continue
+		}
+		fields = append(fields, f)
+	}
+	text := (&Entry{Message: e.Message, Fields: fields}).Text()
+	msg := C.CString(e.File + ":" + strconv.Itoa(e.Line) + "] " + text)
+	C.vglog_os_log(o.logFor(category), osLogType(e.Severity), msg)
+	C.free(unsafe.Pointer(msg))
+	return nil
+}
+
+// Flush 实现Sink, os_log自行缓冲
+func (o *OSLogSink) Flush() error {
+	return nil
+}
+
+func init() {
+	RegisterSink("oslog", func(params map[string]string) (Sink, error) {
+		return NewOSLogSink(params["subsystem"], params["category"]), nil
+	})
+}
@@ -0,0 +1,34 @@
+package logger
+
+import "sync/atomic"
+
+// DiscardSink 丢弃所有日志, 只按日志等级计数; 用于性能测试或只需要统计的部署(配合SetFileOutput(false))
+type DiscardSink struct {
+	counts [maxSeverities]uint64
+}
+
+// NewDiscardSink 创建DiscardSink
+func NewDiscardSink() *DiscardSink {
+	return &DiscardSink{}
+}
+
+// WriteEntry 实现Sink
+func (d *DiscardSink) WriteEntry(e *Entry) error {
+	if e.Severity.valid() {
+		atomic.AddUint64(&d.counts[e.Severity.index()], 1)
+	}
+	return nil
+}
+
+// Flush 实现Sink
+func (d *DiscardSink) Flush() error {
+	return nil
+}
+
+// Count 返回等级s的日志条数
+func (d *DiscardSink) Count(s Severity) uint64 {
+	if !s.valid() {
+		return 0
+	}
+	return atomic.LoadUint64(&d.counts[s.index()])
+}
@@ -0,0 +1,54 @@
+//go:build js && wasm
+// +build js,wasm
+
+package logger
+
+import (
+	"strconv"
+	"syscall/js"
+)
+
+// JSConsoleSink 将日志写入浏览器console.debug/info/warn/error
+type JSConsoleSink struct {
+	console js.Value
+}
+
+// NewJSConsoleSink 创建写入浏览器console的Sink
+func NewJSConsoleSink() *JSConsoleSink {
+	return &JSConsoleSink{console: js.Global().Get("console")}
+}
+
+// consoleMethod maps a severity onto the console method of the same level.
+func consoleMethod(s Severity) string {
+	switch s = s.builtin(); {
+	case s <= SeverityDebug:
+		return "debug"
+	case s == SeverityInfo:
+		return "info"
+	case s == SeverityWarning:
+		return "warn"
+	}
+	return "error"
+}
+
+// WriteEntry 实现Sink
+func (c *JSConsoleSink) WriteEntry(e *Entry) error {
+	c.console.Call(consoleMethod(e.Severity), e.File+":"+strconv.Itoa(e.Line)+"] "+e.Text())
+	return nil
+}
+
+// Flush 实现Sink, console不缓冲
+func (c *JSConsoleSink) Flush() error {
+	return nil
+}
+
+// Browsers have no filesystem for the log files, and stderr already ends up
+// in the console, so the default logger writes to the console sink only.
+func init() {
+	RegisterSink("jsconsole", func(map[string]string) (Sink, error) {
+		return NewJSConsoleSink(), nil
+	})
+	DefaultLogger.SetFileOutput(false)
+	DefaultLogger.noStderr = true
+	DefaultLogger.AddSink(NewJSConsoleSink())
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink 用自己的编码器将日志写入io.Writer
+type WriterSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc Encoder
+}
+
+// NewWriterSink 创建WriterSink, enc为nil时使用TextEncoder
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	if enc == nil {
+		enc = TextEncoder{}
+	}
+	return &WriterSink{w: w, enc: enc}
+}
+
+// WriteEntry 实现Sink
+func (ws *WriterSink) WriteEntry(e *Entry) error {
+	buf := encodeWith(ws.enc, e)
+	ws.mu.Lock()
+	_, err := ws.w.Write(buf.Bytes())
+	ws.mu.Unlock()
+	_bufferPool.release(buf)
+	return err
+}
+
+// Flush 实现Sink
+func (ws *WriterSink) Flush() error {
+	if f, ok := ws.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// writerSinkFactory builds a registry factory for a WriterSink on w; the
+// "encoder" param names a registered encoder.
+func writerSinkFactory(w io.Writer) SinkFactory {
+	return func(params map[string]string) (Sink, error) {
+		var enc Encoder
+		if name := params["encoder"]; name != "" {
+			var err error
+			if enc, err = NewEncoder(name, params); err != nil {
+				return nil, err
+			}
+		}
+		return NewWriterSink(w, enc), nil
+	}
+}
+
+func init() {
+	RegisterSink("stdout", writerSinkFactory(os.Stdout))
+	RegisterSink("stderr", writerSinkFactory(os.Stderr))
+	RegisterEncoder("console", func(params map[string]string) (Encoder, error) {
+		return ConsoleEncoder{Color: params["color"] == "true", Caller: params["caller"] == "true"}, nil
+	})
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Status 日志运行状态
+type Status struct {
+	ClockSteps    uint64        // 检测到系统时间回拨的次数, 回拨处写有标记日志
+	LastClockStep time.Duration // 最近一次回拨的幅度
+	DiskLow       bool          // 磁盘空间不足, 正在丢弃Debug/Info日志
+	WriteStalled  bool          // 文件写入超时, 正在改写stderr
+	ConsoleOnly   bool          // 日志目录不可用, 只输出到stderr
+	Draining      bool          // Drain或Close之后, 新日志被丢弃
+	Dropped       int           // 被丢弃的日志条数
+	Reentered     uint64        // 在Sink或错误回调中写日志的次数, 这些日志改写stderr
+}
+
+// Status 返回日志运行状态
+func (l *Logger) Status() Status {
+	st := Status{
+		ClockSteps:    atomic.LoadUint64(&l.clockSteps),
+		LastClockStep: time.Duration(atomic.LoadInt64(&l.lastClockStep)),
+		DiskLow:       l.lowDisk(),
+		WriteStalled:  l.stalled(),
+		Draining:      l.isDraining(),
+		Dropped:       l.droppedCount(),
+		Reentered:     atomic.LoadUint64(&l.reentered),
+	}
+	if !st.WriteStalled {
+		l.mu.Lock()
+		st.ConsoleOnly = l.consoleOnly
+		l.mu.Unlock()
+	}
+	return st
+}
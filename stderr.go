@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"os"
+	"sync"
+)
+
+// stderrTee mirrors entries to stderr outside of l.mu. Entries are queued by
+// reference while l.mu is held, which keeps them in order, and written out
+// after it is released, several at a time when loggers contend.
+type stderrTee struct {
+	mu      sync.Mutex // guards pending
+	wmu     sync.Mutex // serializes writes to stderr
+	pending []*buffer
+}
+
+// add queues a reference to buf.
+// l.mu is held.
+func (t *stderrTee) add(buf *buffer) {
+	buf.retain()
+	t.mu.Lock()
+	t.pending = append(t.pending, buf)
+	t.mu.Unlock()
+}
+
+// flush writes all queued entries to stderr with one vectored write.
+func (t *stderrTee) flush() {
+	t.wmu.Lock()
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(pending) > 0 {
+		bufs := make([][]byte, len(pending))
+		for i, b := range pending {
+			bufs[i] = b.Bytes()
+		}
+		writeBuffers(os.Stderr, bufs) // ignore err
+		for _, b := range pending {
+			_bufferPool.release(b)
+		}
+	}
+	t.wmu.Unlock()
+}
+
+// SetStderrTee 设置stderr镜像输出是否在锁外以引用方式批量写出
+func (l *Logger) SetStderrTee(enabled bool) {
+	l.mu.Lock()
+	l.stderrTee = enabled
+	l.mu.Unlock()
+}
+
+// SetStderrEncoder 设置stderr镜像输出的编码器, 可与日志文件使用不同格式(如ConsoleEncoder); nil表示与日志文件相同
+func (l *Logger) SetStderrEncoder(enc Encoder) {
+	l.mu.Lock()
+	l.stderrEncoder = enc
+	l.mu.Unlock()
+}
+
+// SetStderrThreshold 设置镜像到stderr的最低日志级别, 与SetSeverityLimit相互独立;
+// 未设置时日志级别为Debug及以下才镜像全部日志到stderr
+func (l *Logger) SetStderrThreshold(s Severity) {
+	l.mu.Lock()
+	l.stderrLevel, l.stderrLevelSet = s, true
+	l.updateSinkFloor()
+	l.mu.Unlock()
+}
+
+// SetStderrOutput 设置是否镜像日志到stderr, 关闭后日志文件不可用时仍会改写stderr以免丢失
+func (l *Logger) SetStderrOutput(enabled bool) {
+	l.mu.Lock()
+	l.noStderr = !enabled
+	l.updateSinkFloor()
+	l.mu.Unlock()
+}
+
+// ConsoleSplit 按等级分流镜像的控制台输出: 低于At的日志写stdout, 其余写stderr, 便于shell管道分开常规输出与问题;
+// NoStdout与NoStderr分别关闭一侧
+type ConsoleSplit struct {
+	At       Severity
+	NoStdout bool
+	NoStderr bool
+}
+
+// SetConsoleSplit 设置控制台分流策略, 如&ConsoleSplit{At: SeverityWarning}使Debug/Info写stdout、Warning及以上写stderr;
+// 镜像哪些日志仍由SetStderrThreshold决定; nil表示全部写stderr(默认)
+func (l *Logger) SetConsoleSplit(cs *ConsoleSplit) {
+	if cs != nil {
+		c := *cs
+		cs = &c
+	}
+	l.mu.Lock()
+	l.consoleSplit = cs
+	l.mu.Unlock()
+}
+
+// consoleOut returns the stream a mirrored entry of severity s goes to,
+// nil to drop it.
+// l.mu is held.
+func (l *Logger) consoleOut(s Severity) *os.File {
+	cs := l.consoleSplit
+	switch {
+	case cs == nil:
+		return os.Stderr
+	case !s.atLeast(cs.At):
+		if cs.NoStdout {
+			return nil
+		}
+		return os.Stdout
+	case cs.NoStderr:
+		return nil
+	}
+	return os.Stderr
+}
+
+// mirrors reports whether an entry of severity s is mirrored to stderr.
+// l.mu is held.
+func (l *Logger) mirrors(s, slimit Severity) bool {
+	switch {
+	case l.noStderr:
+		return false
+	case l.stderrLevelSet:
+		return s.atLeast(l.stderrLevel)
+	}
+	return SeverityDebug.atLeast(slimit)
+}
+
+// mirrorStderr writes e to stderr, reusing the file encoding in buf unless
+// stderr has its own encoder. It reports whether the entry was queued on the
+// tee and still needs l.stderr.flush after l.mu is released.
+// l.mu is held.
+func (l *Logger) mirrorStderr(e *Entry, buf *buffer) bool {
+	out := l.consoleOut(e.Severity)
+	if out == nil {
+		return false
+	}
+	if l.stderrEncoder != nil {
+		buf = encodeWith(l.stderrEncoder, e)
+		defer _bufferPool.release(buf)
+	}
+	if out == os.Stderr && l.stderrTee {
+		l.stderr.add(buf)
+		return true
+	}
+	out.Write(buf.Bytes())
+	return false
+}
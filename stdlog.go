@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"io"
+	"log"
+)
+
+// SourceKey 日志字段, 标明经桥接写入的日志的来源, 如"stdlib"、"grpc"、"child:worker-3"
+const SourceKey = "source"
+
+// SeverityMap 桥接的等级重映射表, 键为来源库报告的等级, 值为写入的等级, 如{SeverityInfo: SeverityDebug}
+// 使话多的依赖库不占用Info日志量; 不在表中的等级不变
+type SeverityMap map[Severity]Severity
+
+// apply returns the severity s is remapped to.
+func (m SeverityMap) apply(s Severity) Severity {
+	if t, ok := m[s]; ok {
+		return t
+	}
+	return s
+}
+
+// Bridge 第三方日志库(标准库log、grpclog等)到Logger的桥接, 来源等级经重映射后写入
+type Bridge struct {
+	l      *Logger
+	remap  SeverityMap
+	source string
+}
+
+// NewBridge 创建桥接, remap为该来源的等级重映射表, 可为nil
+func (l *Logger) NewBridge(remap SeverityMap) *Bridge {
+	m := make(SeverityMap, len(remap))
+	for from, to := range remap {
+		m[from] = to
+	}
+	return &Bridge{l: l, remap: m}
+}
+
+// SetSource 设置经该桥接写入的日志的source字段, 如"grpc", 使混合来源的日志文件仍可区分; 须在Writer与StdLogger之前调用
+func (b *Bridge) SetSource(source string) {
+	b.source = source
+}
+
+// Writer 返回以来源等级s写入的io.Writer, 每次Write为一条日志, 调用位置取自log.Logger.Output的调用者;
+// 可用于log.New或grpclog.NewLoggerV2(b.Writer(SeverityInfo), b.Writer(SeverityWarning), b.Writer(SeverityError))
+func (b *Bridge) Writer(s Severity) io.Writer {
+	return stdLogBridge{l: b.l, s: b.remap.apply(s), source: b.source}
+}
+
+// StdLogger 返回以来源等级s写入的*log.Logger, 如用作http.Server.ErrorLog
+func (b *Bridge) StdLogger(s Severity) *log.Logger {
+	return log.New(b.Writer(s), "", 0)
+}
+
+// stdLogBridge writes standard library log output into a Logger.
+type stdLogBridge struct {
+	l      *Logger
+	s      Severity
+	source string
+}
+
+// Write is called by log.Output on behalf of the log.Print family, so
+// the original caller sits two frames above Write.
+func (b stdLogBridge) Write(p []byte) (int, error) {
+	if !b.l.enabled(b.s) {
+		return len(p), nil
+	}
+	e := b.l.newEntry(b.s, 1, string(p))
+	if b.source != "" {
+		e.Fields = append([]Field{{Key: SourceKey, Value: b.source}}, e.Fields...)
+	}
+	b.l.log(e)
+	return len(p), nil
+}
+
+// CopyStandardLogTo 将标准库log包的全局输出转入本Logger的s级别, 保留调用位置, 日志带有source=stdlib字段
+func (l *Logger) CopyStandardLogTo(s Severity) {
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(stdLogBridge{l: l, s: s, source: "stdlib"})
+}
+
+// CopyStandardLogTo 默认logger快捷调用
+func CopyStandardLogTo(s Severity) {
+	DefaultLogger.CopyStandardLogTo(s)
+}
@@ -0,0 +1,72 @@
+package logger
+
+// AddDebugTarget 登记名为name的定向条件: pred返回true的日志条目(如某个用户的请求)
+// 不受SetSeverityLimit限制, 同SetCohortSampling选中的条目一样写出; 同名条件会被替换.
+// pred在每条低于级别的日志上调用, 须快速且不写日志
+func (l *Logger) AddDebugTarget(name string, pred func(e *Entry) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.targets()
+	targets := make(map[string]func(*Entry) bool, len(old)+1)
+	for n, p := range old {
+		targets[n] = p
+	}
+	targets[name] = pred
+	l.debugTargets.Store(targets)
+}
+
+// RemoveDebugTarget 移除名为name的定向条件
+func (l *Logger) RemoveDebugTarget(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.targets()
+	if _, ok := old[name]; !ok {
+		return
+	}
+	targets := make(map[string]func(*Entry) bool, len(old))
+	for n, p := range old {
+		if n != name {
+			targets[n] = p
+		}
+	}
+	l.debugTargets.Store(targets)
+}
+
+func (l *Logger) targets() map[string]func(*Entry) bool {
+	m, _ := l.debugTargets.Load().(map[string]func(*Entry) bool)
+	return m
+}
+
+// targeting reports whether entries below the severity limit may still
+// be selected, so they have to be built before being dropped.
+func (l *Logger) targeting() bool {
+	return l.cohortRule() != nil || len(l.targets()) > 0
+}
+
+// debugTargeted reports whether a registered target selects e.
+func (l *Logger) debugTargeted(e *Entry) bool {
+	for _, pred := range l.targets() {
+		if pred(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldIn 返回定向条件: 条目带有字段key且值(文本形式)在values中
+func FieldIn(key string, values ...string) func(e *Entry) bool {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return func(e *Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				if _, ok := set[f.String()]; ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import "sync/atomic"
+
+// Verbose 由V返回, 级别未开启时其方法不写日志
+type Verbose struct {
+	l  *Logger
+	on bool
+}
+
+// SetVerbosity 设置V日志的详细级别, V(level)在level<=v时开启
+func (l *Logger) SetVerbosity(v int) {
+	atomic.StoreInt32(&l.verbosity, int32(v))
+}
+
+// Verbosity 返回V日志的详细级别
+func (l *Logger) Verbosity() int {
+	return int(atomic.LoadInt32(&l.verbosity))
+}
+
+// V 返回详细级别为level的Verbose, 用法同glog: l.V(2).Infof(...); 也可先判断l.V(2).Enabled()再构造参数
+func (l *Logger) V(level int) Verbose {
+	return l.vDepth(1, level)
+}
+
+// Enabled 返回该级别是否开启
+func (v Verbose) Enabled() bool {
+	return v.on
+}
+
+// Info 级别开启时写Info日志
+func (v Verbose) Info(args ...interface{}) {
+	if v.on {
+		v.l.println(SeverityInfo, args...)
+	}
+}
+
+// Infof 级别开启时写格式化Info日志
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.on {
+		v.l.printf(SeverityInfo, format, args...)
+	}
+}
+
+// V 默认logger快捷调用
+func V(level int) Verbose {
+	return DefaultLogger.vDepth(1, level)
+}
+
+// SetVerbosity 默认logger快捷调用
+func SetVerbosity(v int) {
+	DefaultLogger.SetVerbosity(v)
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// modulePat is one "pattern=N" entry of a vmodule spec.
+type modulePat struct {
+	pattern string
+	full    bool // the pattern has a '/' and matches the whole path
+	level   int32
+}
+
+// moduleSpec holds the vmodule patterns and caches the level resolved for
+// each V call site.
+type moduleSpec struct {
+	mu       sync.RWMutex
+	filter   []modulePat
+	set      int32
+	spec     string
+	levelsBy map[uintptr]int32
+}
+
+func (m *moduleSpec) active() bool {
+	return atomic.LoadInt32(&m.set) != 0
+}
+
+// level resolves the verbosity for the V call site at pc in file.
+func (m *moduleSpec) level(pc uintptr, file string) int32 {
+	m.mu.RLock()
+	v, ok := m.levelsBy[pc]
+	m.mu.RUnlock()
+	if ok {
+		return v
+	}
+	file = strings.TrimSuffix(file, ".go")
+	base := filepath.Base(file)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v = 0
+	for _, f := range m.filter {
+		name := base
+		if f.full {
+			name = file
+		}
+		if ok, _ := filepath.Match(f.pattern, name); ok {
+			v = f.level
+			break
+		}
+	}
+	m.levelsBy[pc] = v
+	return v
+}
+
+var errVModuleSyntax = errors.New("logger: syntax error: expect comma-separated list of pattern=N")
+
+// SetVModule 按源文件设置V日志的详细级别, 格式同glog的-vmodule, 如"conn*=3,db=1":
+// 模式匹配不含.go后缀的文件名, 含'/'的模式匹配完整路径; 未匹配的文件使用SetVerbosity的级别, 空串清除
+func (l *Logger) SetVModule(spec string) error {
+	filter, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	m := &l.vmodule
+	m.mu.Lock()
+	m.filter = filter
+	m.spec = spec
+	m.levelsBy = make(map[uintptr]int32)
+	m.mu.Unlock()
+	var set int32
+	if len(filter) > 0 {
+		set = 1
+	}
+	atomic.StoreInt32(&m.set, set)
+	return nil
+}
+
+func parseVModule(spec string) ([]modulePat, error) {
+	var filter []modulePat
+	for _, pat := range strings.Split(spec, ",") {
+		if pat == "" {
+			continue
+		}
+		patLev := strings.Split(pat, "=")
+		if len(patLev) != 2 || patLev[0] == "" || patLev[1] == "" {
+			return nil, errVModuleSyntax
+		}
+		v, err := strconv.ParseInt(patLev[1], 10, 32)
+		if err != nil {
+			return nil, errVModuleSyntax
+		}
+		if v < 0 {
+			return nil, errors.New("logger: negative value for vmodule level")
+		}
+		if _, err := filepath.Match(patLev[0], ""); err != nil {
+			return nil, err
+		}
+		filter = append(filter, modulePat{
+			pattern: strings.TrimSuffix(patLev[0], ".go"),
+			full:    strings.ContainsRune(patLev[0], '/'),
+			level:   int32(v),
+		})
+	}
+	return filter, nil
+}
+
+// VModule 返回SetVModule设置的配置
+func (l *Logger) VModule() string {
+	l.vmodule.mu.RLock()
+	defer l.vmodule.mu.RUnlock()
+	return l.vmodule.spec
+}
+
+// VDepth 同V, 按向上跳过depth层栈帧的调用位置匹配SetVModule的配置, 用于封装V的函数
+func (l *Logger) VDepth(depth, level int) Verbose {
+	return l.vDepth(depth+1, level)
+}
+
+// vDepth resolves V for the call site depth frames above its caller.
+func (l *Logger) vDepth(depth, level int) Verbose {
+	if int32(level) <= atomic.LoadInt32(&l.verbosity) {
+		return Verbose{l: l, on: true}
+	}
+	if !l.vmodule.active() {
+		return Verbose{l: l}
+	}
+	pc, file, _, ok := runtime.Caller(depth + 1)
+	if !ok {
+		return Verbose{l: l}
+	}
+	return Verbose{l: l, on: int32(level) <= l.vmodule.level(pc, file)}
+}
+
+// SetVModule 默认logger快捷调用
+func SetVModule(spec string) error {
+	return DefaultLogger.SetVModule(spec)
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"testing"
+)
+
+func TestParseVModule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []modulePat
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "conn*=3,db=1", want: []modulePat{{"conn*", false, 3}, {"db", false, 1}}},
+		{spec: "db.go=2,", want: []modulePat{{"db", false, 2}}},
+		{spec: "svc/*/db=4", want: []modulePat{{"svc/*/db", true, 4}}},
+		{spec: "db", wantErr: true},
+		{spec: "=1", wantErr: true},
+		{spec: "db=", wantErr: true},
+		{spec: "db=x", wantErr: true},
+		{spec: "db=-1", wantErr: true},
+		{spec: "db=1=2", wantErr: true},
+		{spec: "[=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseVModule(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseVModule(%q) error %v", tt.spec, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseVModule(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseVModule(%q) = %+v, want %+v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestVModuleLevel(t *testing.T) {
+	tests := []struct {
+		spec string
+		file string
+		want int32
+	}{
+		{"conn*=3,db=1", "/src/svc/connpool.go", 3},
+		{"conn*=3,db=1", "/src/svc/db.go", 1},
+		{"conn*=3,db=1", "/src/svc/dbx.go", 0},
+		{"db=1,d*=2", "/src/svc/db.go", 1}, // first match wins
+		{"svc/*/db=4", "svc/store/db.go", 4},
+		{"/src/*/store/db=4", "/src/svc/store/db.go", 4},
+		{"store/db=4", "/src/svc/store/db.go", 0}, // full patterns match the whole path
+	}
+	for i, tt := range tests {
+		filter, err := parseVModule(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &moduleSpec{filter: filter, levelsBy: make(map[uintptr]int32)}
+		pc := uintptr(i + 1)
+		if got := m.level(pc, tt.file); got != tt.want {
+			t.Errorf("%s: level(%s) = %d, want %d", tt.spec, tt.file, got, tt.want)
+		}
+		// The level is cached per call site.
+		if got := m.level(pc, "other.go"); got != tt.want {
+			t.Errorf("%s: cached level = %d, want %d", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestVModule(t *testing.T) {
+	tests := []struct {
+		verbosity int
+		spec      string
+		want      []string
+	}{
+		{0, "", []string{"INFO v0"}},
+		{1, "", []string{"INFO v0", "INFO v1"}},
+		{0, "vmodule_test=2", []string{"INFO v0", "INFO v1", "INFO v2"}},
+		{0, "vmodule_*=1", []string{"INFO v0", "INFO v1"}},
+		{0, "other=3", []string{"INFO v0"}},
+		{2, "vmodule_test=1", []string{"INFO v0", "INFO v1", "INFO v2"}}, // the higher level applies
+	}
+	for _, tt := range tests {
+		l, r := newRecordedLogger(t)
+		l.SetVerbosity(tt.verbosity)
+		if err := l.SetVModule(tt.spec); err != nil {
+			t.Fatal(err)
+		}
+		if got := l.VModule(); got != tt.spec {
+			t.Errorf("VModule() = %q, want %q", got, tt.spec)
+		}
+		for v := 0; v <= 3; v++ {
+			l.V(v).Infof("v%d", v)
+		}
+		if got := r.take(); !equalNames(got, tt.want) {
+			t.Errorf("verbosity %d, vmodule %q: logged %q, want %q", tt.verbosity, tt.spec, got, tt.want)
+		}
+	}
+}
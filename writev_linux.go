@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// maxIovecs is the IOV_MAX limit on the number of buffers per writev call.
+const maxIovecs = 1024
+
+// writeBuffers writes bufs to f with as few writev calls as possible, so the
+// kernel copies each entry straight out of its pooled buffer.
+func writeBuffers(f *os.File, bufs [][]byte) (int64, error) {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	var werr error
+	iovs := make([]syscall.Iovec, 0, maxIovecs)
+	err = rc.Write(func(fd uintptr) bool {
+		for len(bufs) > 0 {
+			iovs = iovs[:0]
+			for _, b := range bufs {
+				if len(iovs) == maxIovecs {
+					break
+				}
+				if len(b) == 0 {
+					continue
+				}
+				iov := syscall.Iovec{Base: &b[0]}
+				iov.SetLen(len(b))
+				iovs = append(iovs, iov)
+			}
+			if len(iovs) == 0 {
+				return true
+			}
+			n, _, errno := syscall.Syscall(syscall.SYS_WRITEV, fd, uintptr(unsafe.Pointer(&iovs[0])), uintptr(len(iovs)))
+			if errno == syscall.EINTR {
+				continue
+			}
+			if errno == syscall.EAGAIN {
+				return false
+			}
+			if errno != 0 {
+				werr = errno
+				return true
+			}
+			total += int64(n)
+			bufs = consumeBuffers(bufs, int64(n))
+		}
+		return true
+	})
+	if err == nil {
+		err = werr
+	}
+	return total, err
+}
+
+// consumeBuffers drops the first n bytes from bufs.
+func consumeBuffers(bufs [][]byte, n int64) [][]byte {
+	for len(bufs) > 0 && int64(len(bufs[0])) <= n {
+		n -= int64(len(bufs[0]))
+		bufs = bufs[1:]
+	}
+	if len(bufs) > 0 {
+		bufs[0] = bufs[0][n:]
+	}
+	return bufs
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package logger
+
+import (
+	"os"
+)
+
+// writeBuffers writes bufs to f. Without writev the buffers are gathered
+// into a single copy so the file still sees one write.
+func writeBuffers(f *os.File, bufs [][]byte) (int64, error) {
+	size := 0
+	for _, b := range bufs {
+		size += len(b)
+	}
+	data := make([]byte, 0, size)
+	for _, b := range bufs {
+		data = append(data, b...)
+	}
+	n, err := f.Write(data)
+	return int64(n), err
+}